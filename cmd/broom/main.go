@@ -49,28 +49,77 @@ func main() {
 	excludeTypes := flag.String("x", "", "Comma-separated list of cleanup types to exclude")
 	includeTypes := flag.String("i", "", "Comma-separated list of cleanup types to include")
 	allFlag := flag.Bool("all", false, "Apply all removal types")
+	removePackages := flag.String("remove-packages", "", "Comma-separated list of package names, globs and /regexes/ for the packages cleaner")
+	keepRotations := flag.Int("keep-rotations", cleaners.DefaultOptions().LogKeepRotations, "Maximum number of rotated generations of each log to keep, below the rotate count of its logrotate rule (0 disables the limit)")
+	logAction := flag.String("log-action", cleaners.DefaultOptions().LogAction, "What the logs and user_logs cleaners do with eligible files: delete or compress")
+	keepVersions := flag.Int("keep-versions", cleaners.DefaultOptions().KeepCacheVersions, "Number of versions of each installed package to keep in package manager caches")
+	keepCoredumps := flag.Int("keep-coredumps", cleaners.DefaultOptions().KeepCoredumps, "Number of core dumps and crash reports of each executable to keep")
+	coredumpDays := flag.Int("coredump-days", cleaners.DefaultOptions().CoredumpMaxAgeDays, "Remove core dumps and crash reports older than this many days (0 keeps them regardless of age)")
+	journalSize := flag.String("journal-size", "", "Vacuum the journal to this size, e.g. 500M (default: SystemMaxUse from journald.conf)")
+	journalTime := flag.String("journal-time", "", "Vacuum journal entries older than this, e.g. 2weeks (default: MaxRetentionSec from journald.conf)")
+	journalFiles := flag.Int("journal-files", 0, "Vacuum the journal to this many archived files (default: SystemMaxFiles from journald.conf)")
+	cacheDays := flag.Int("cache-days", cleaners.DefaultOptions().CacheMaxAgeDays, "Remove user cache files not used for this many days (0 removes every file)")
+	cacheInclude := flag.String("cache-include", "", "Comma-separated list of ~/.cache application directories (names, globs, /regexes/) the cache cleaner is limited to")
+	cacheExclude := flag.String("cache-exclude", "", "Comma-separated list of ~/.cache application directories (names, globs, /regexes/) the cache cleaner leaves alone")
+	trashDays := flag.Int("trash-days", cleaners.DefaultOptions().TrashMaxAgeDays, "Empty trash items deleted more than this many days ago (0 empties the whole trash)")
+	recentDays := flag.Int("recent-days", cleaners.DefaultOptions().RecentFilesMaxAgeDays, "Drop recently used files entries older than this many days (0 disables the limit)")
+	recentMax := flag.Int("recent-max", cleaners.DefaultOptions().RecentFilesMaxEntries, "Maximum number of recently used files entries kept (0 disables the limit)")
+	jetbrainsConfigs := flag.Bool("jetbrains-configs", false, "Also remove the configs of old JetBrains IDE versions")
+	projectDays := flag.Int("project-days", cleaners.DefaultOptions().ProjectMaxAgeDays, "Remove build artifacts of projects whose source has not changed for this many days")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [-x exclude_types] [-i include_types] [--all]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [-x exclude_types] [-i include_types] [--all] [options]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nAvailable cleanup types:\n")
 		for _, t := range cleaners.GetAllCleanupTypes() {
+			if cleaner, _ := cleaners.GetCleaner(t); cleaner.Category != "" {
+				fmt.Fprintf(os.Stderr, "  %s (%s, only run with -i)\n", t, cleaner.Category)
+				continue
+			}
 			fmt.Fprintf(os.Stderr, "  %s\n", t)
 		}
+		fmt.Fprintf(os.Stderr, "\nUse -i %s to run every privacy cleaner.\n", cleaners.CategoryPrivacy)
 	}
 
 	flag.Parse()
 
 	typesToRun, err := parseFlags(*excludeTypes, *includeTypes, *allFlag)
+	if err == nil && *logAction != "delete" && *logAction != "compress" {
+		err = fmt.Errorf("invalid log action: %s", *logAction)
+	}
 	if err != nil {
 		fmt.Println(au.Red(fmt.Sprintf("Error: %s", err)))
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	options := cleaners.DefaultOptions()
+	options.RemovePackages = splitList(*removePackages)
+	options.KeepCacheVersions = *keepVersions
+	options.LogKeepRotations = *keepRotations
+	options.LogAction = *logAction
+	options.KeepCoredumps = *keepCoredumps
+	options.CoredumpMaxAgeDays = *coredumpDays
+	options.JournalVacuumSize = *journalSize
+	options.JournalVacuumTime = *journalTime
+	options.JournalVacuumFiles = *journalFiles
+	options.TrashMaxAgeDays = *trashDays
+	options.CacheMaxAgeDays = *cacheDays
+	options.CacheInclude = splitList(*cacheInclude)
+	options.CacheExclude = splitList(*cacheExclude)
+	options.RecentFilesMaxAgeDays = *recentDays
+	options.RecentFilesMaxEntries = *recentMax
+	options.JetBrainsRemoveConfigs = *jetbrainsConfigs
+	options.ProjectMaxAgeDays = *projectDays
+	cleaners.SetOptions(options)
+
 	utils.PrintBanner()
 
+	for _, warning := range cleaners.PreRunWarnings() {
+		fmt.Println(au.Yellow(fmt.Sprintf("Warning: %s", warning)))
+	}
+
 	startSpace := utils.GetFreeDiskSpace()
 	fmt.Println(au.Blue(fmt.Sprintf("Free disk space before cleanup: %s", utils.FormatBytes(startSpace))))
 
@@ -248,9 +297,13 @@ func parseFlags(excludeTypes, includeTypes string, allFlag bool) ([]string, erro
 	var typesToRun []string
 
 	if allFlag {
-		typesToRun = cleanupTypes
+		typesToRun = cleaners.GetDefaultCleanupTypes()
 	} else if includeTypes != "" {
 		for _, t := range strings.Split(includeTypes, ",") {
+			if category := cleaners.GetCleanupTypesInCategory(t); len(category) > 0 {
+				typesToRun = append(typesToRun, category...)
+				continue
+			}
 			if !contains(cleanupTypes, t) {
 				return nil, fmt.Errorf("invalid cleanup type: %s", t)
 			}
@@ -264,7 +317,7 @@ func parseFlags(excludeTypes, includeTypes string, allFlag bool) ([]string, erro
 			}
 			excludeMap[t] = true
 		}
-		for _, t := range cleanupTypes {
+		for _, t := range cleaners.GetDefaultCleanupTypes() {
 			if !excludeMap[t] {
 				typesToRun = append(typesToRun, t)
 			}
@@ -274,6 +327,16 @@ func parseFlags(excludeTypes, includeTypes string, allFlag bool) ([]string, erro
 	return typesToRun, nil
 }
 
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
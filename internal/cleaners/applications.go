@@ -3,6 +3,9 @@ package cleaners
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/cosmix/broom/internal/utils"
@@ -17,6 +20,7 @@ func init() {
 	registerCleanup("python", Cleaner{CleanupFunc: cleanPythonCache, RequiresConfirmation: false})
 	registerCleanup("libreoffice", Cleaner{CleanupFunc: cleanLibreOfficeCache, RequiresConfirmation: false})
 	registerCleanup("browser", Cleaner{CleanupFunc: clearBrowserCaches, RequiresConfirmation: false})
+	registerCleanup("sqlite_vacuum", Cleaner{CleanupFunc: vacuumSQLiteDatabases(utils.CommandExists), RequiresConfirmation: false})
 	registerCleanup("package_manager", Cleaner{CleanupFunc: cleanPackageManagerCaches(utils.CommandExists), RequiresConfirmation: false})
 	registerCleanup("npm", Cleaner{CleanupFunc: cleanNpmCache(utils.CommandExists), RequiresConfirmation: false})
 	registerCleanup("yarn", Cleaner{CleanupFunc: cleanYarnCache(utils.CommandExists), RequiresConfirmation: false})
@@ -31,6 +35,7 @@ func init() {
 	registerCleanup("composer", Cleaner{CleanupFunc: cleanComposerCache(utils.CommandExists), RequiresConfirmation: false})
 	registerCleanup("wine", Cleaner{CleanupFunc: removeOldWinePrefixes(utils.CommandExists), RequiresConfirmation: false})
 	registerCleanup("electron", Cleaner{CleanupFunc: cleanElectronCache, RequiresConfirmation: false})
+	registerCleanup("vscode", Cleaner{CleanupFunc: cleanVSCode, RequiresConfirmation: false})
 	registerCleanup("kdenlive", Cleaner{CleanupFunc: cleanKdenliveRenderFiles(utils.CommandExists), RequiresConfirmation: true})
 	registerCleanup("blender", Cleaner{CleanupFunc: cleanBlenderTempFiles(utils.CommandExists), RequiresConfirmation: true})
 	registerCleanup("steam", Cleaner{CleanupFunc: cleanSteamDownloadCache(utils.CommandExists), RequiresConfirmation: true})
@@ -46,7 +51,9 @@ func init() {
 	registerCleanup("julia_packages", Cleaner{CleanupFunc: cleanJuliaPackagesCache(utils.CommandExists), RequiresConfirmation: true})
 	registerCleanup("conda", Cleaner{CleanupFunc: cleanUnusedCondaEnvironments(utils.CommandExists), RequiresConfirmation: true})
 	registerCleanup("mercurial", Cleaner{CleanupFunc: cleanMercurialBackups(utils.CommandExists), RequiresConfirmation: true})
+	registerCleanup("git", Cleaner{CleanupFunc: cleanGitRepos(utils.CommandExists), RequiresConfirmation: true})
 	registerCleanup("git_lfs", Cleaner{CleanupFunc: cleanGitLFSCache(utils.CommandExists), RequiresConfirmation: true})
+	registerCleanup("project_artifacts", Cleaner{CleanupFunc: cleanProjectArtifacts, RequiresConfirmation: true})
 	registerCleanup("cmake", Cleaner{CleanupFunc: cleanCMakeBuildDirs, RequiresConfirmation: true})
 	registerCleanup("autotools", Cleaner{CleanupFunc: cleanAutotoolsFiles, RequiresConfirmation: true})
 	registerCleanup("ccache", Cleaner{CleanupFunc: cleanCCache(utils.CommandExists), RequiresConfirmation: true})
@@ -115,17 +122,33 @@ func cleanRubyGems(commandExists utils.CommandExistsFunc) func() error {
 }
 
 func cleanPythonCache() error {
-	err := utils.Runner.RunFdOrFind("/home /tmp", "-type d -name __pycache__ -exec rm -rf {} +", "Removing Python cache files", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while removing Python cache files: %v\n", err)
-	}
-	err = utils.Runner.RunFdOrFind("/home /tmp", "-name '*.pyc' -delete", "Removing .pyc files", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while removing .pyc files: %v\n", err)
-	}
+	d := newDeleter()
+	cleanPythonCacheIn([]string{"/home", "/tmp"}, d)
+	d.report("Python cache file(s)")
 	return nil
 }
 
+func cleanPythonCacheIn(roots []string, d *deleter) {
+	for _, root := range roots {
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if entry.Name() == "__pycache__" {
+					d.removeTree(path)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(entry.Name(), ".pyc") {
+				d.removeFile(path)
+			}
+			return nil
+		})
+	}
+}
+
 func cleanLibreOfficeCache() error {
 	err := utils.Runner.RunFdOrFind("/home", "-type d -path '*/.config/libreoffice/4/user/uno_packages/cache' -exec rm -rf {}/* \\;", "Clearing LibreOffice cache", true)
 	if err != nil {
@@ -135,37 +158,34 @@ func cleanLibreOfficeCache() error {
 }
 
 func clearBrowserCaches() error {
-	err := utils.Runner.RunFdOrFind("/home", "-type d -path '*/.cache/google-chrome/Default/Cache' -exec rm -rf {}/* \\;", "Clearing Chrome cache", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while clearing Chrome cache: %v\n", err)
-	}
-	err = utils.Runner.RunFdOrFind("/home", "-type d -path '*/.cache/chromium/Default/Cache' -exec rm -rf {}/* \\;", "Clearing Chromium cache", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while clearing Chromium cache: %v\n", err)
-	}
-	err = utils.Runner.RunFdOrFind("/home", "-type d -path '*/.mozilla/firefox/*/Cache' -exec rm -rf {}/* \\;", "Clearing Firefox cache", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while clearing Firefox cache: %v\n", err)
+	d := newDeleter()
+	for _, home := range userHomes(passwdFile) {
+		clearBrowserCachesIn(home.dir, procDir, d)
 	}
+	d.report("browser cache file(s)")
 	return nil
 }
 
 func cleanPackageManagerCaches(commandExists utils.CommandExistsFunc) func() error {
+	return cleanPackageManagerCachesIn(commandExists, dpkgStatusFile, aptArchivesDir, rpmCacheDirs, pacmanCacheDir)
+}
+
+func cleanPackageManagerCachesIn(commandExists utils.CommandExistsFunc, statusFile, aptDir string, rpmDirs []string, pacmanDir string) func() error {
 	return func() error {
 		if commandExists("apt-get") {
-			err := utils.Runner.RunWithIndicator("apt-get clean", "Cleaning APT cache")
+			err := pruneAptCache(aptDir, statusFile, options.KeepCacheVersions)
 			if err != nil {
 				return err
 			}
 		}
-		if commandExists("yum") {
-			err := utils.Runner.RunWithIndicator("yum clean all", "Cleaning YUM cache")
+		if commandExists("rpm") && (commandExists("dnf") || commandExists("yum")) {
+			err := pruneRpmCache(rpmDirs, options.KeepCacheVersions)
 			if err != nil {
 				return err
 			}
 		}
-		if commandExists("dnf") {
-			return utils.Runner.RunWithIndicator("dnf clean all", "Cleaning DNF cache")
+		if commandExists("pacman") {
+			return prunePacmanCache(pacmanDir, options.KeepCacheVersions)
 		}
 		return nil
 	}
@@ -274,14 +294,6 @@ func removeOldWinePrefixes(commandExists utils.CommandExistsFunc) func() error {
 	}
 }
 
-func cleanElectronCache() error {
-	err := utils.Runner.RunFdOrFind("/home", "-type d -path '*/.config/*electron*' -exec rm -rf {}/* \\;", "Clearing Electron cache", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while clearing Electron cache: %v\n", err)
-	}
-	return nil
-}
-
 func cleanKdenliveRenderFiles(commandExists utils.CommandExistsFunc) func() error {
 	return func() error {
 		if commandExists("kdenlive") {
@@ -408,17 +420,6 @@ func cleanAndroidSDK(commandExists utils.CommandExistsFunc) func() error {
 	}
 }
 
-func cleanJetBrainsIDECaches() func() error {
-	return func() error {
-		jetbrainsDir := "~/.local/share/JetBrains"
-		err := utils.Runner.RunWithIndicator(fmt.Sprintf("find %s -type d -name '.caches' -exec rm -rf {} +", jetbrainsDir), "Cleaning JetBrains IDE caches")
-		if err != nil {
-			return fmt.Errorf("failed to clean JetBrains IDE caches: %v", err)
-		}
-		return nil
-	}
-}
-
 func cleanRPackagesCache(commandExists utils.CommandExistsFunc) func() error {
 	return func() error {
 		if !commandExists("R") {
@@ -510,51 +511,101 @@ func cleanGitLFSCache(commandExists utils.CommandExistsFunc) func() error {
 			return nil
 		}
 
-		err := utils.Runner.RunWithIndicator("git lfs prune", "Cleaning Git LFS cache")
-		if err != nil {
-			return fmt.Errorf("failed to clean Git LFS cache: %v", err)
-		}
-
+		repos, owners := homeGitRepos()
+		pruneGitLFS(repos, owners)
 		return nil
 	}
 }
 
-func cleanCMakeBuildDirs() error {
-	err := utils.Runner.RunFdOrFind("/home", "-type d -name 'build' -exec test -f '{}/CMakeCache.txt' \\; -exec rm -rf {} \\;", "Removing old CMake build directories", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while removing CMake build directories: %v\n", err)
+func pruneGitLFS(repos []gitRepo, owners map[int]string) {
+	for _, repo := range repos {
+		if !usesGitLFS(repo) {
+			continue
+		}
+		command, ok := gitCommand(repo, owners, "lfs prune")
+		if !ok {
+			fmt.Printf("Skipping %s: owned by unknown uid %d\n", repo.dir, repo.uid)
+			continue
+		}
+		if err := utils.Runner.RunWithIndicator(command, "Cleaning Git LFS cache in "+repo.dir); err != nil {
+			fmt.Printf("Warning: Failed to clean Git LFS cache in %s: %v\n", repo.dir, err)
+		}
 	}
+}
 
-	err = utils.Runner.RunFdOrFind("/home", "-type d -name 'CMakeFiles' -exec rm -rf {} \\;", "Removing CMakeFiles directories", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while removing CMakeFiles directories: %v\n", err)
+func cleanCMakeBuildDirs() error {
+	homes := userHomes(passwdFile)
+	r := newArtifactRemover(newDeleter(), userNames(homes))
+	for _, home := range homes {
+		cleanCMakeBuildDirsIn(home.dir, r)
 	}
-
+	r.report("CMake build file(s)")
 	return nil
 }
 
-func cleanAutotoolsFiles() error {
-	patterns := []string{
-		"autom4te.cache",
-		"config.status",
-		"config.log",
-		"configure~",
-		"Makefile.in",
-		"aclocal.m4",
-		".deps",
-		".libs",
-	}
-
-	for _, pattern := range patterns {
-		err := utils.Runner.RunFdOrFind("/home", fmt.Sprintf("-type d,f -name '%s' -exec rm -rf {} \\;", pattern), fmt.Sprintf("Removing Autotools generated %s", pattern), true)
-		if err != nil {
-			fmt.Printf("Warning: Error while removing Autotools %s: %v\n", pattern, err)
+func cleanCMakeBuildDirsIn(root string, r *artifactRemover) {
+	walkArtifacts(root, func(path string, entry fs.DirEntry) bool {
+		if !entry.IsDir() {
+			return false
 		}
-	}
+		if entry.Name() == "CMakeFiles" {
+			return true
+		}
+		_, err := os.Stat(filepath.Join(path, "CMakeCache.txt"))
+		return entry.Name() == "build" && err == nil
+	}, r)
+}
 
+var autotoolsArtifacts = map[string]bool{
+	"autom4te.cache": true,
+	"config.status":  true,
+	"config.log":     true,
+	"configure~":     true,
+	"Makefile.in":    true,
+	"aclocal.m4":     true,
+	".deps":          true,
+	".libs":          true,
+}
+
+func cleanAutotoolsFiles() error {
+	homes := userHomes(passwdFile)
+	r := newArtifactRemover(newDeleter(), userNames(homes))
+	for _, home := range homes {
+		cleanAutotoolsFilesIn(home.dir, r)
+	}
+	r.report("Autotools generated file(s)")
 	return nil
 }
 
+func cleanAutotoolsFilesIn(root string, r *artifactRemover) {
+	walkArtifacts(root, func(_ string, entry fs.DirEntry) bool {
+		return autotoolsArtifacts[entry.Name()]
+	}, r)
+}
+
+// walkArtifacts does not search hidden directories such as .git and ~/.cache.
+func walkArtifacts(root string, match func(path string, entry fs.DirEntry) bool, r *artifactRemover) {
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if match(path, entry) {
+			r.remove(path)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
 func cleanCCache(commandExists utils.CommandExistsFunc) func() error {
 	return func() error {
 		if !commandExists("ccache") {
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -70,6 +73,10 @@ func (m *MockRunner) RunWithOutput(command string) (string, error) {
 	return "", nil
 }
 
+func (m *MockRunner) RunWithIO(command string, stdin io.Reader, stdout io.Writer) error {
+	return copyStdin(command, stdin, stdout)
+}
+
 func TestCleanDocker(t *testing.T) {
 	originalRunner := utils.Runner
 	defer func() { utils.Runner = originalRunner }()
@@ -286,33 +293,25 @@ func TestCleanRubyGems(t *testing.T) {
 }
 
 func TestCleanPythonCache(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() { utils.Runner = originalRunner }()
-
-	tests := []struct {
-		name        string
-		fdOrFindErr error
-		expectErr   bool
-	}{
-		{"Success", nil, false},
-		{"FdOrFindError", errors.New("fd error"), false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{fdOrFindErr: tt.fdOrFindErr}
-			utils.Runner = mock
-
-			err := cleanPythonCache()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanPythonCache() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunFdOrFindCalls) != 2 {
-				t.Errorf("Expected 2 calls to RunFdOrFind, got %d", len(mock.RunFdOrFindCalls))
-			}
-		})
+	home := t.TempDir()
+	tmp := t.TempDir()
+	writeTestFiles(t, home,
+		"alice/app/__pycache__/mod.cpython-312.pyc",
+		"alice/app/__pycache__/util.cpython-312.pyc",
+		"alice/app/legacy.pyc",
+		"alice/app/mod.py",
+	)
+	writeTestFiles(t, tmp, "pytest-run/__pycache__/conftest.cpython-312.pyc")
+
+	running := filepath.Join(tmp, "pytest-run/__pycache__/conftest.cpython-312.pyc")
+	d := &deleter{open: map[string][]int{running: {99}}}
+	cleanPythonCacheIn([]string{home, tmp}, d)
+
+	assertFilesRemoved(t, home, "alice/app/__pycache__", "alice/app/legacy.pyc")
+	assertFilesExist(t, home, "alice/app/mod.py")
+	assertFilesExist(t, tmp, "pytest-run/__pycache__/conftest.cpython-312.pyc")
+	if d.removed != 3 {
+		t.Errorf("Expected 3 files removed, got %d", d.removed)
 	}
 }
 
@@ -348,34 +347,70 @@ func TestCleanLibreOfficeCache(t *testing.T) {
 }
 
 func TestClearBrowserCaches(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() { utils.Runner = originalRunner }()
-
-	tests := []struct {
-		name        string
-		fdOrFindErr error
-		expectErr   bool
-	}{
-		{"Success", nil, false},
-		{"FdOrFindError", errors.New("fd error"), false},
+	home := t.TempDir()
+	proc := t.TempDir()
+	writeTestFiles(t, home,
+		".config/google-chrome/Default/Preferences",
+		".config/google-chrome/Default/Code Cache/js/index",
+		".config/google-chrome/Default/GPUCache/data_0",
+		".config/google-chrome/Default/Service Worker/CacheStorage/abc/entry",
+		".config/google-chrome/Default/Service Worker/Database/LOG",
+		".config/google-chrome/Default/History",
+		".config/google-chrome/Profile 2/Preferences",
+		".cache/google-chrome/Default/Cache/Cache_Data/data_1",
+		".cache/google-chrome/Profile 2/Cache/Cache_Data/data_1",
+		".config/BraveSoftware/Brave-Browser/Default/GPUCache/data_0",
+		".config/opera/Preferences",
+		".cache/opera/Cache/Cache_Data/data_0",
+		".var/app/org.chromium.Chromium/config/chromium/Default/GPUCache/data_0",
+		".mozilla/firefox/abcd1234.default-release/places.sqlite",
+		".mozilla/firefox/efgh5678.work/places.sqlite",
+		".cache/mozilla/firefox/abcd1234.default-release/cache2/entries/ABCDEF",
+		".cache/mozilla/firefox/efgh5678.work/cache2/entries/123456",
+	)
+	profilesIni := "[Install4F96D1932A9F858E]\nDefault=abcd1234.default-release\n\n" +
+		"[Profile1]\nName=work\nIsRelative=1\nPath=efgh5678.work\n\n" +
+		"[Profile0]\nName=default-release\nIsRelative=1\nPath=abcd1234.default-release\nDefault=1\n\n" +
+		"[General]\nStartWithLastProfile=1\nVersion=2\n"
+	if err := os.WriteFile(filepath.Join(home, ".mozilla/firefox/profiles.ini"), []byte(profilesIni), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Brave and the "work" Firefox profile are running; Chrome left a stale lock.
+	if err := os.MkdirAll(filepath.Join(proc, "4321"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		".config/BraveSoftware/Brave-Browser/SingletonLock": "my-host-4321",
+		".config/google-chrome/SingletonLock":               "my-host-9999",
+		".mozilla/firefox/efgh5678.work/lock":               "127.0.1.1:+4321",
+	} {
+		if err := os.Symlink(target, filepath.Join(home, link)); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{fdOrFindErr: tt.fdOrFindErr}
-			utils.Runner = mock
-
-			err := clearBrowserCaches()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("clearBrowserCaches() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunFdOrFindCalls) != 3 {
-				t.Errorf("Expected 3 calls to RunFdOrFind, got %d", len(mock.RunFdOrFindCalls))
-			}
-		})
-	}
+	d := &deleter{}
+	clearBrowserCachesIn(home, proc, d)
+
+	assertFilesRemoved(t, home,
+		".config/google-chrome/Default/Code Cache/js",
+		".config/google-chrome/Default/GPUCache/data_0",
+		".config/google-chrome/Default/Service Worker/CacheStorage/abc",
+		".cache/google-chrome/Default/Cache/Cache_Data",
+		".cache/google-chrome/Profile 2/Cache/Cache_Data",
+		".cache/opera/Cache/Cache_Data",
+		".var/app/org.chromium.Chromium/config/chromium/Default/GPUCache/data_0",
+		".cache/mozilla/firefox/abcd1234.default-release/cache2/entries",
+	)
+	assertFilesExist(t, home,
+		".config/google-chrome/Default/GPUCache",
+		".config/google-chrome/Default/History",
+		".config/google-chrome/Default/Service Worker/Database/LOG",
+		".config/BraveSoftware/Brave-Browser/Default/GPUCache/data_0",
+		".mozilla/firefox/abcd1234.default-release/places.sqlite",
+		".cache/mozilla/firefox/efgh5678.work/cache2/entries/123456",
+	)
 }
 
 func TestCleanPackageManagerCaches(t *testing.T) {
@@ -385,55 +420,37 @@ func TestCleanPackageManagerCaches(t *testing.T) {
 	tests := []struct {
 		name          string
 		commandExists utils.CommandExistsFunc
-		withIndErr    error
+		outputErr     error
 		expectErr     bool
-		expectedCalls int
 	}{
-		{
-			"AllPackageManagersInstalled",
-			func(cmd string) bool { return true },
-			nil,
-			false,
-			3,
-		},
-		{
-			"NoPackageManagersInstalled",
-			func(cmd string) bool { return false },
-			nil,
-			false,
-			0,
-		},
-		{
-			"AptGetError",
-			func(cmd string) bool { return cmd == "apt-get" },
-			errors.New("run error"),
-			true,
-			1,
-		},
+		{"AllPackageManagersInstalled", func(cmd string) bool { return true }, nil, false},
+		{"NoPackageManagersInstalled", func(cmd string) bool { return false }, nil, false},
+		{"RpmListError", func(cmd string) bool { return cmd != "apt-get" }, errors.New("rpm error"), true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mock := &MockRunner{}
 			utils.Runner = mock
+			if tt.outputErr != nil {
+				mock.RunWithOutputCalls = []RunWithOutputCall{{Err: tt.outputErr}}
+			}
 
-			if tt.withIndErr != nil {
-				mock.RunWithIndicatorCalls = []RunWithIndicatorCall{{
-					Command: "apt-get clean",
-					Message: "Cleaning APT cache",
-					Err:     tt.withIndErr,
-				}}
+			dir := t.TempDir()
+			statusFile := filepath.Join(dir, "status")
+			if err := os.WriteFile(statusFile, []byte(testDpkgStatus), 0644); err != nil {
+				t.Fatal(err)
 			}
 
-			cleanFunc := cleanPackageManagerCaches(tt.commandExists)
+			cleanFunc := cleanPackageManagerCachesIn(tt.commandExists, statusFile, filepath.Join(dir, "apt"), []string{filepath.Join(dir, "dnf")}, filepath.Join(dir, "pacman"))
 			err := cleanFunc()
 
 			if (err != nil) != tt.expectErr {
 				t.Errorf("cleanPackageManagerCaches() error = %v, expectErr %v", err, tt.expectErr)
 			}
 
-			if len(mock.RunWithIndicatorCalls) != tt.expectedCalls {
-				t.Errorf("Expected %d call(s) to RunWithIndicator, got %d", tt.expectedCalls, len(mock.RunWithIndicatorCalls))
+			if len(mock.RunWithIndicatorCalls) != 0 {
+				t.Errorf("Expected no calls to RunWithIndicator, got %d", len(mock.RunWithIndicatorCalls))
 			}
 		})
 	}
@@ -936,33 +953,60 @@ func TestRemoveOldWinePrefixes(t *testing.T) {
 }
 
 func TestCleanElectronCache(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() { utils.Runner = originalRunner }()
-
-	tests := []struct {
-		name        string
-		fdOrFindErr error
-		expectErr   bool
-	}{
-		{"Success", nil, false},
-		{"FdOrFindError", errors.New("fd error"), false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{fdOrFindErr: tt.fdOrFindErr}
-			utils.Runner = mock
-
-			err := cleanElectronCache()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanElectronCache() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunFdOrFindCalls) != 1 {
-				t.Errorf("Expected 1 call to RunFdOrFind, got %d", len(mock.RunFdOrFindCalls))
-			}
-		})
+	home := t.TempDir()
+	proc := t.TempDir()
+	writeTestFiles(t, home,
+		".config/Slack/Local State",
+		".config/Slack/Cache/Cache_Data/data_0",
+		".config/Slack/Code Cache/js/index",
+		".config/Slack/Crashpad/completed/report.dmp",
+		".config/Slack/Local Storage/leveldb/000003.log",
+		".config/Slack/storage/root-state.json",
+		".config/Code/Preferences",
+		".config/Code/CachedData/1a2b/chrome.bin",
+		".config/Code/User/settings.json",
+		".config/Microsoft/Microsoft Teams/Session Storage/000003.log",
+		".config/Microsoft/Microsoft Teams/GPUCache/data_0",
+		".config/Signal/Local State",
+		".config/Signal/GPUCache/data_0",
+		".config/myapp-electron/config.json",
+		".config/myapp-electron/Cache/keep",
+		".config/chromium/Local State",
+		".config/chromium/Default/Cache/data_0",
+		".var/app/com.discordapp.Discord/config/discord/Local State",
+		".var/app/com.discordapp.Discord/config/discord/Cache/Cache_Data/data_0",
+	)
+	// Signal is running.
+	if err := os.MkdirAll(filepath.Join(proc, "4321"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("my-host-4321", filepath.Join(home, ".config/Signal/SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &deleter{}
+	cleanElectronCachesIn(home, proc, d)
+
+	assertFilesRemoved(t, home,
+		".config/Slack/Cache/Cache_Data",
+		".config/Slack/Code Cache/js",
+		".config/Slack/Crashpad/completed",
+		".config/Code/CachedData/1a2b",
+		".config/Microsoft/Microsoft Teams/GPUCache/data_0",
+		".var/app/com.discordapp.Discord/config/discord/Cache/Cache_Data",
+	)
+	assertFilesExist(t, home,
+		".config/Slack/Cache",
+		".config/Slack/Local State",
+		".config/Slack/Local Storage/leveldb/000003.log",
+		".config/Slack/storage/root-state.json",
+		".config/Code/User/settings.json",
+		".config/Signal/GPUCache/data_0",
+		".config/myapp-electron/Cache/keep",
+		".config/chromium/Default/Cache/data_0",
+	)
+	if d.removed != 6 {
+		t.Errorf("Expected 6 files removed, got %d", d.removed)
 	}
 }
 
@@ -1499,51 +1543,56 @@ func TestCleanAndroidSDK(t *testing.T) {
 }
 
 func TestCleanJetBrainsIDECaches(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() {
-		utils.Runner = originalRunner
-	}()
-
 	tests := []struct {
 		name          string
-		withIndErr    error
-		expectErr     bool
-		expectedCalls int
+		removeConfigs bool
 	}{
-		{"Success", nil, false, 1},
-		{"Error", errors.New("clean error"), true, 1},
+		{"KeepConfigs", false},
+		{"RemoveConfigs", true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{}
-			utils.Runner = mock
-
-			if tt.withIndErr != nil {
-				mock.RunWithIndicatorCalls = []RunWithIndicatorCall{{
-					Command: "find ~/.local/share/JetBrains -type d -name '.caches' -exec rm -rf {} +",
-					Message: "Cleaning JetBrains IDE caches",
-					Err:     tt.withIndErr,
-				}}
-			}
-
-			cleanFunc := cleanJetBrainsIDECaches()
-			err := cleanFunc()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanJetBrainsIDECaches() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunWithIndicatorCalls) != tt.expectedCalls {
-				t.Errorf("Expected %d call(s) to RunWithIndicator, got %d", tt.expectedCalls, len(mock.RunWithIndicatorCalls))
-			}
-
-			if len(mock.RunWithIndicatorCalls) > 0 {
-				call := mock.RunWithIndicatorCalls[0]
-				expectedCmd := "find ~/.local/share/JetBrains -type d -name '.caches' -exec rm -rf {} +"
-				if call.Command != expectedCmd || call.Message != "Cleaning JetBrains IDE caches" {
-					t.Errorf("Unexpected arguments to RunWithIndicator: %+v", call)
-				}
+			home := t.TempDir()
+			writeTestFiles(t, home,
+				".cache/JetBrains/PyCharm2021.1/index/big.dat",
+				".cache/JetBrains/PyCharm2023.2/log/idea.log",
+				".cache/JetBrains/PyCharm2024.1/caches/content.dat",
+				".local/share/JetBrains/PyCharm2021.1/plugins/x.jar",
+				".local/share/JetBrains/PyCharm2024.1/.caches/stale",
+				".config/JetBrains/PyCharm2021.1/options/ide.general.xml",
+				".cache/JetBrains/IntelliJIdea2022.3/index/big.dat",
+				".cache/JetBrains/IntelliJIdea2023.1/index/big.dat",
+				".local/share/JetBrains/Toolbox/apps/IDEA-U/product-info.json",
+				".cache/JetBrains/Toolbox/logs/toolbox.log",
+			)
+			info := `{"name": "IntelliJ IDEA", "version": "2022.3.3", "dataDirectoryName": "IntelliJIdea2022.3"}`
+			toolbox := filepath.Join(home, ".local/share/JetBrains/Toolbox/apps/IDEA-U/product-info.json")
+			if err := os.WriteFile(toolbox, []byte(info), 0644); err != nil {
+				t.Fatal(err)
+			}
+			installed := installedJetBrainsVersions([]string{filepath.Join(home, jetbrainsToolboxPatterns[0])})
+
+			d := &deleter{}
+			cleanJetBrainsIn(home, installed, tt.removeConfigs, d)
+
+			assertFilesRemoved(t, home,
+				".cache/JetBrains/PyCharm2021.1",
+				".cache/JetBrains/PyCharm2023.2",
+				".local/share/JetBrains/PyCharm2021.1",
+				".local/share/JetBrains/PyCharm2024.1/.caches",
+			)
+			assertFilesExist(t, home,
+				".cache/JetBrains/PyCharm2024.1/caches/content.dat",
+				".cache/JetBrains/IntelliJIdea2022.3/index/big.dat",
+				".cache/JetBrains/IntelliJIdea2023.1/index/big.dat",
+				".local/share/JetBrains/Toolbox/apps/IDEA-U/product-info.json",
+				".cache/JetBrains/Toolbox/logs/toolbox.log",
+			)
+			if tt.removeConfigs {
+				assertFilesRemoved(t, home, ".config/JetBrains/PyCharm2021.1")
+			} else {
+				assertFilesExist(t, home, ".config/JetBrains/PyCharm2021.1/options/ide.general.xml")
 			}
 		})
 	}
@@ -1843,178 +1892,75 @@ func TestCleanMercurialBackups(t *testing.T) {
 }
 
 func TestCleanGitLFSCache(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() { utils.Runner = originalRunner }()
+	mock := setupTest()
+	home := t.TempDir()
+	writeTestFiles(t, home, "lfs-repo/.git/lfs/objects/ab/cd/abcd", "lfs-repo/.git/HEAD", "plain-repo/.git/HEAD")
+	repos := findGitRepos(home)
 
-	tests := []struct {
-		name          string
-		commandExists utils.CommandExistsFunc
-		withIndErr    error
-		expectErr     bool
-		expectedCalls int
-	}{
-		{
-			name:          "GitLFSInstalled",
-			commandExists: func(cmd string) bool { return true },
-			expectedCalls: 1,
-		},
-		{
-			name:          "GitLFSNotInstalled",
-			commandExists: func(cmd string) bool { return false },
-			expectedCalls: 0,
-		},
-		{
-			name:          "RunWithIndicatorError",
-			commandExists: func(cmd string) bool { return true },
-			withIndErr:    errors.New("run error"),
-			expectErr:     true,
-			expectedCalls: 1,
-		},
+	if err := cleanGitLFSCache(func(string) bool { return false })(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(mock.Commands) != 0 {
+		t.Errorf("Expected no commands when git-lfs is not installed, got %v", mock.Commands)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{}
-			utils.Runner = mock
-
-			if tt.withIndErr != nil {
-				mock.RunWithIndicatorCalls = []RunWithIndicatorCall{{
-					Command: "git lfs prune",
-					Message: "Cleaning Git LFS cache",
-					Err:     tt.withIndErr,
-				}}
-			}
-
-			cleanFunc := cleanGitLFSCache(tt.commandExists)
-			err := cleanFunc()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanGitLFSCache() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunWithIndicatorCalls) != tt.expectedCalls {
-				t.Errorf("Expected %d call(s) to RunWithIndicator, got %d", tt.expectedCalls, len(mock.RunWithIndicatorCalls))
-			}
-
-			if len(mock.RunWithIndicatorCalls) > 0 {
-				call := mock.RunWithIndicatorCalls[0]
-				if call.Command != "git lfs prune" || call.Message != "Cleaning Git LFS cache" {
-					t.Errorf("Unexpected arguments to RunWithIndicator: %+v", call)
-				}
-			}
-		})
+	mock.RunWithIndicatorFunc = func(command, message string) error { return errors.New("prune error") }
+	pruneGitLFS(repos, map[int]string{})
+	expected := []string{"git -C '" + filepath.Join(home, "lfs-repo") + "' lfs prune"}
+	if !reflect.DeepEqual(mock.Commands, expected) {
+		t.Errorf("Expected %v, got %v", expected, mock.Commands)
 	}
 }
 
 func TestCleanCMakeBuildDirs(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() { utils.Runner = originalRunner }()
-
-	tests := []struct {
-		name        string
-		fdOrFindErr error
-		expectErr   bool
-	}{
-		{
-			name: "Success",
-		},
-		{
-			name:        "FdOrFindError",
-			fdOrFindErr: errors.New("fd error"),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{fdOrFindErr: tt.fdOrFindErr}
-			utils.Runner = mock
-
-			err := cleanCMakeBuildDirs()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanCMakeBuildDirs() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunFdOrFindCalls) != 2 {
-				t.Errorf("Expected 2 calls to RunFdOrFind, got %d", len(mock.RunFdOrFindCalls))
-			}
-
-			if len(mock.RunFdOrFindCalls) > 0 {
-				call1 := mock.RunFdOrFindCalls[0]
-				if call1.Dir != "/home" || call1.Args != "-type d -name 'build' -exec test -f '{}/CMakeCache.txt' \\; -exec rm -rf {} \\;" ||
-					call1.Message != "Removing old CMake build directories" || !call1.Sudo {
-					t.Errorf("Unexpected arguments to first RunFdOrFind: %+v", call1)
-				}
-
-				if len(mock.RunFdOrFindCalls) > 1 {
-					call2 := mock.RunFdOrFindCalls[1]
-					if call2.Dir != "/home" || call2.Args != "-type d -name 'CMakeFiles' -exec rm -rf {} \\;" ||
-						call2.Message != "Removing CMakeFiles directories" || !call2.Sudo {
-						t.Errorf("Unexpected arguments to second RunFdOrFind: %+v", call2)
-					}
-				}
-			}
-		})
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"src/app/CMakeLists.txt",
+		"src/app/build/CMakeCache.txt",
+		"src/app/build/bin/app",
+		"src/app/out/CMakeFiles/app.dir/main.o",
+		"src/web/build/index.html",
+		".cache/cmake/CMakeFiles/x",
+	)
+
+	d := &deleter{}
+	cleanCMakeBuildDirsIn(home, newArtifactRemover(d, nil))
+
+	assertFilesRemoved(t, home, "src/app/build", "src/app/out/CMakeFiles")
+	assertFilesExist(t, home, "src/app/CMakeLists.txt", "src/app/out", "src/web/build/index.html", ".cache/cmake/CMakeFiles/x")
+	if d.removed != 3 {
+		t.Errorf("Expected 3 files removed, got %d", d.removed)
 	}
 }
 
 func TestCleanAutotoolsFiles(t *testing.T) {
-	originalRunner := utils.Runner
-	defer func() { utils.Runner = originalRunner }()
-
-	patterns := []string{
-		"autom4te.cache",
-		"config.status",
-		"config.log",
-		"configure~",
-		"Makefile.in",
-		"aclocal.m4",
-		".deps",
-		".libs",
-	}
-
-	tests := []struct {
-		name        string
-		fdOrFindErr error
-		expectErr   bool
-	}{
-		{
-			name: "Success",
-		},
-		{
-			name:        "FdOrFindError",
-			fdOrFindErr: errors.New("fd error"),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := &MockRunner{fdOrFindErr: tt.fdOrFindErr}
-			utils.Runner = mock
-
-			err := cleanAutotoolsFiles()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanAutotoolsFiles() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.RunFdOrFindCalls) != len(patterns) {
-				t.Errorf("Expected %d calls to RunFdOrFind, got %d", len(patterns), len(mock.RunFdOrFindCalls))
-			}
-
-			for i, pattern := range patterns {
-				if i < len(mock.RunFdOrFindCalls) {
-					call := mock.RunFdOrFindCalls[i]
-					expectedArgs := fmt.Sprintf("-type d,f -name '%s' -exec rm -rf {} \\;", pattern)
-					expectedMessage := fmt.Sprintf("Removing Autotools generated %s", pattern)
-					if call.Dir != "/home" || call.Args != expectedArgs ||
-						call.Message != expectedMessage || !call.Sudo {
-						t.Errorf("Unexpected arguments for pattern %s: %+v", pattern, call)
-					}
-				}
-			}
-		})
-	}
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"src/lib/configure.ac",
+		"src/lib/Makefile.am",
+		"src/lib/Makefile.in",
+		"src/lib/aclocal.m4",
+		"src/lib/config.log",
+		"src/lib/config.status",
+		"src/lib/autom4te.cache/output.0",
+		"src/lib/src/.deps/main.Po",
+		"src/lib/src/.libs/libfoo.so",
+		".cache/build/config.log",
+	)
+
+	d := &deleter{}
+	cleanAutotoolsFilesIn(home, newArtifactRemover(d, nil))
+
+	assertFilesRemoved(t, home,
+		"src/lib/Makefile.in",
+		"src/lib/aclocal.m4",
+		"src/lib/config.log",
+		"src/lib/config.status",
+		"src/lib/autom4te.cache",
+		"src/lib/src/.deps",
+		"src/lib/src/.libs",
+	)
+	assertFilesExist(t, home, "src/lib/configure.ac", "src/lib/Makefile.am", ".cache/build/config.log")
 }
 
 func TestCleanCCache(t *testing.T) {
@@ -0,0 +1,293 @@
+package cleaners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const (
+	bootDir           = "/boot"
+	mountsFile        = "/proc/mounts"
+	bootUsageWarnPerc = 80.0
+)
+
+var bootKernelPrefixes = []string{"vmlinuz-", "initrd.img-", "initramfs-", "initrd-", "System.map-", "config-"}
+
+type mountEntry struct {
+	device     string
+	mountPoint string
+	fsType     string
+}
+
+type bootKernel struct {
+	version string
+	files   []string
+	size    uint64
+}
+
+func init() {
+	registerCleanup("boot", Cleaner{CleanupFunc: cleanBoot, RequiresConfirmation: true})
+	registerPreRunCheck(checkBootPressure)
+}
+
+func checkBootPressure() string {
+	underPressure, usage := bootPressure(bootDir, mountsFile)
+	if !underPressure {
+		return ""
+	}
+	return fmt.Sprintf("/boot is %.0f%% full; kernel updates may fail. Consider running the 'boot' cleaner.", usage)
+}
+
+func cleanBoot() error {
+	return cleanBootPartition(bootDir, mountsFile, utils.CommandExists)
+}
+
+func cleanBootPartition(dir, mounts string, commandExists utils.CommandExistsFunc) error {
+	underPressure, usage := bootPressure(dir, mounts)
+	if !underPressure {
+		fmt.Println("/boot cleanup: Skipped (not a separate mount or below usage threshold)")
+		return nil
+	}
+	fmt.Printf("/boot is %.0f%% full\n", usage)
+
+	running, err := runningKernel()
+	if err != nil {
+		return fmt.Errorf("failed to determine running kernel: %v", err)
+	}
+
+	stale, err := staleBootKernels(dir, running)
+	if err != nil {
+		return fmt.Errorf("failed to list kernels in %s: %v", dir, err)
+	}
+	if len(stale) == 0 {
+		fmt.Println("No removable kernels found in /boot (only the running and newest kernels are present)")
+		return nil
+	}
+
+	for _, k := range stale {
+		fmt.Printf("  %s (%s)\n", k.version, utils.FormatBytes(k.size))
+		for _, f := range k.files {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+
+	if !commandExists("dpkg") || !commandExists("apt-get") {
+		fmt.Println("/boot cleanup: Skipped (kernel removal is only supported on dpkg-based systems)")
+		return nil
+	}
+
+	var packages []string
+	for _, k := range stale {
+		owners, ok := bootFileOwners(k.files)
+		if !ok {
+			fmt.Printf("Keeping %s (unable to determine the package owning its files)\n", k.version)
+			continue
+		}
+		if len(owners) == 0 {
+			// Leftovers of a kernel whose package is already gone.
+			for _, f := range k.files {
+				if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+					fmt.Printf("Warning: Failed to remove %s: %v\n", f, err)
+				}
+			}
+			continue
+		}
+		packages = append(packages, owners...)
+	}
+
+	if len(packages) == 0 {
+		return nil
+	}
+	return utils.Runner.RunWithIndicator(fmt.Sprintf("apt-get -y purge %s", strings.Join(packages, " ")), "Removing old kernels from /boot...")
+}
+
+// bootPressure reports whether dir is a separate mount above the usage threshold.
+func bootPressure(dir, mounts string) (bool, float64) {
+	f, err := os.Open(mounts)
+	if err != nil {
+		return false, 0
+	}
+	defer f.Close()
+
+	if !isMountPoint(parseMounts(f), dir) {
+		return false, 0
+	}
+	usage, err := diskUsagePercent(dir)
+	if err != nil {
+		return false, 0
+	}
+	return usage >= bootUsageWarnPerc, usage
+}
+
+func parseMounts(r io.Reader) []mountEntry {
+	var entries []mountEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		entries = append(entries, mountEntry{
+			device:     unescapeMountField(fields[0]),
+			mountPoint: unescapeMountField(fields[1]),
+			fsType:     fields[2],
+		})
+	}
+	return entries
+}
+
+// unescapeMountField decodes the octal escapes (\040 for space) used in /proc/mounts.
+func unescapeMountField(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isMountPoint(entries []mountEntry, dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, e := range entries {
+		if filepath.Clean(e.mountPoint) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+func diskUsagePercent(path string) (float64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	used := stat.Blocks - stat.Bfree
+	total := used + stat.Bavail
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(used) * 100 / float64(total), nil
+}
+
+func runningKernel() (string, error) {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// staleBootKernels returns every kernel in dir except the running and the newest one.
+func staleBootKernels(dir, running string) ([]bootKernel, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	kernels := make(map[string]*bootKernel)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		version := bootFileVersion(entry.Name())
+		if version == "" {
+			continue
+		}
+		k, ok := kernels[version]
+		if !ok {
+			k = &bootKernel{version: version}
+			kernels[version] = k
+		}
+		k.files = append(k.files, filepath.Join(dir, entry.Name()))
+		if info, err := entry.Info(); err == nil {
+			k.size += uint64(info.Size())
+		}
+	}
+
+	versions := make([]string, 0, len(kernels))
+	for v := range kernels {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+
+	var stale []bootKernel
+	for i, v := range versions {
+		if v == running || i == len(versions)-1 {
+			continue
+		}
+		stale = append(stale, *kernels[v])
+	}
+	return stale, nil
+}
+
+func bootFileVersion(name string) string {
+	for _, prefix := range bootKernelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			version := strings.TrimPrefix(name, prefix)
+			version = strings.TrimSuffix(version, ".img")
+			if version == "" || !isDigit(version[0]) || strings.HasSuffix(version, ".old") || strings.HasSuffix(version, ".bak") {
+				return ""
+			}
+			return version
+		}
+	}
+	return ""
+}
+
+// bootFileOwners returns ok only when dpkg named an owner or reported none for each file.
+func bootFileOwners(files []string) (owners []string, ok bool) {
+	seen := make(map[string]bool)
+	for _, f := range files {
+		output, err := utils.Runner.RunWithOutput(fmt.Sprintf("LC_ALL=C dpkg -S %s 2>&1 || true", shellQuote(f)))
+		if err != nil {
+			return nil, false
+		}
+		if strings.Contains(output, "no path found matching pattern") {
+			continue
+		}
+		found := false
+		for _, line := range strings.Split(output, "\n") {
+			pkg, path, ok := strings.Cut(line, ": ")
+			if !ok || path != f || strings.HasPrefix(pkg, "diversion by") {
+				continue
+			}
+			for _, p := range strings.Split(pkg, ", ") {
+				p = strings.TrimSpace(p)
+				if p == "" {
+					continue
+				}
+				found = true
+				if !seen[p] {
+					seen[p] = true
+					owners = append(owners, p)
+				}
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return owners, true
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,111 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMounts(t *testing.T) {
+	input := `proc /proc proc rw,relatime 0 0
+/dev/sda2 / ext4 rw,relatime 0 0
+/dev/sda1 /boot ext4 rw,relatime 0 0
+/dev/sdb1 /media/my\040disk vfat rw 0 0
+`
+	entries := parseMounts(strings.NewReader(input))
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 mount entries, got %d", len(entries))
+	}
+	if entries[3].mountPoint != "/media/my disk" {
+		t.Errorf("Expected escaped mount point to be decoded, got %q", entries[3].mountPoint)
+	}
+	if !isMountPoint(entries, "/boot") {
+		t.Error("Expected /boot to be detected as a mount point")
+	}
+	if isMountPoint(entries, "/var") {
+		t.Error("Expected /var not to be detected as a mount point")
+	}
+}
+
+func TestBootPressureNotMounted(t *testing.T) {
+	dir := t.TempDir()
+	mounts := filepath.Join(dir, "mounts")
+	writeTestFile(t, mounts, "/dev/sda2 / ext4 rw 0 0\n")
+
+	underPressure, _ := bootPressure(dir, mounts)
+	if underPressure {
+		t.Error("Expected no /boot pressure when /boot is not a separate mount")
+	}
+}
+
+func TestStaleBootKernels(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"vmlinuz-5.15.0-88-generic", "initrd.img-5.15.0-88-generic", "System.map-5.15.0-88-generic",
+		"vmlinuz-5.15.0-91-generic", "initrd.img-5.15.0-91-generic",
+		"vmlinuz-5.15.0-100-generic", "initrd.img-5.15.0-100-generic",
+		"vmlinuz-5.15.0-101-generic", "initrd.img-5.15.0-101-generic",
+		"grub", "memtest86+.bin",
+	}
+	for _, f := range files {
+		writeTestFile(t, filepath.Join(dir, f), "kernel")
+	}
+	if err := os.Symlink("vmlinuz-5.15.0-101-generic", filepath.Join(dir, "vmlinuz")); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := staleBootKernels(dir, "5.15.0-91-generic")
+	if err != nil {
+		t.Fatalf("staleBootKernels() error = %v", err)
+	}
+
+	var versions []string
+	for _, k := range stale {
+		versions = append(versions, k.version)
+	}
+	expected := []string{"5.15.0-88-generic", "5.15.0-100-generic"}
+	if strings.Join(versions, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected stale kernels %v, got %v", expected, versions)
+	}
+	if len(stale[0].files) != 3 {
+		t.Errorf("Expected 3 files for the oldest kernel, got %d", len(stale[0].files))
+	}
+	if stale[0].size != 18 {
+		t.Errorf("Expected size 18 for the oldest kernel, got %d", stale[0].size)
+	}
+}
+
+func TestBootFileOwners(t *testing.T) {
+	mock := setupTest()
+	mock.RunWithOutputFunc = func(command string) (string, error) {
+		switch {
+		case strings.Contains(command, "vmlinuz-5.15.0-88-generic"):
+			return "linux-image-5.15.0-88-generic: /boot/vmlinuz-5.15.0-88-generic\n", nil
+		case strings.Contains(command, "initrd.img-5.15.0-88-generic"), strings.Contains(command, "vmlinuz-5.4.0-42-generic"):
+			return "dpkg-query: no path found matching pattern " + command + "\n", nil
+		}
+		return "dpkg-query: error: parsing file '/var/lib/dpkg/status' near line 12\n", nil
+	}
+
+	owners, ok := bootFileOwners([]string{"/boot/vmlinuz-5.15.0-88-generic", "/boot/initrd.img-5.15.0-88-generic"})
+	if !ok || len(owners) != 1 || owners[0] != "linux-image-5.15.0-88-generic" {
+		t.Errorf("Expected [linux-image-5.15.0-88-generic], got %v, %v", owners, ok)
+	}
+	if !strings.Contains(mock.Commands[0], "'/boot/vmlinuz-5.15.0-88-generic'") {
+		t.Errorf("Expected the path to be quoted, got %q", mock.Commands[0])
+	}
+
+	// Only files dpkg says no package owns are leftovers.
+	if owners, ok := bootFileOwners([]string{"/boot/vmlinuz-5.4.0-42-generic"}); !ok || len(owners) != 0 {
+		t.Errorf("Expected no owners, got %v, %v", owners, ok)
+	}
+	// Any other answer leaves the owner unknown.
+	if _, ok := bootFileOwners([]string{"/boot/vmlinuz-5.4.0-42-generic", "/boot/System.map-5.4.0-42-generic"}); ok {
+		t.Error("Expected the owner to be unknown when dpkg fails")
+	}
+	mock.RunWithOutputFunc = func(string) (string, error) { return "", os.ErrPermission }
+	if _, ok := bootFileOwners([]string{"/boot/vmlinuz-5.4.0-42-generic"}); ok {
+		t.Error("Expected the owner to be unknown when dpkg cannot be run")
+	}
+}
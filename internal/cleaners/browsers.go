@@ -0,0 +1,257 @@
+package cleaners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+type browserInstall struct {
+	name   string
+	config string
+	cache  string
+}
+
+var chromiumBrowsers = []browserInstall{
+	{"Chrome", ".config/google-chrome", ".cache/google-chrome"},
+	{"Chrome Beta", ".config/google-chrome-beta", ".cache/google-chrome-beta"},
+	{"Chrome Unstable", ".config/google-chrome-unstable", ".cache/google-chrome-unstable"},
+	{"Chromium", ".config/chromium", ".cache/chromium"},
+	{"Brave", ".config/BraveSoftware/Brave-Browser", ".cache/BraveSoftware/Brave-Browser"},
+	{"Edge", ".config/microsoft-edge", ".cache/microsoft-edge"},
+	{"Edge Beta", ".config/microsoft-edge-beta", ".cache/microsoft-edge-beta"},
+	{"Edge Dev", ".config/microsoft-edge-dev", ".cache/microsoft-edge-dev"},
+	{"Vivaldi", ".config/vivaldi", ".cache/vivaldi"},
+	{"Opera", ".config/opera", ".cache/opera"},
+	{"Chromium (snap)", "snap/chromium/common/chromium", "snap/chromium/common/.cache/chromium"},
+	{"Brave (snap)", "snap/brave/current/.config/BraveSoftware/Brave-Browser", "snap/brave/current/.cache/BraveSoftware/Brave-Browser"},
+	{"Opera (snap)", "snap/opera/current/.config/opera", "snap/opera/current/.cache/opera"},
+	{"Chrome (flatpak)", ".var/app/com.google.Chrome/config/google-chrome", ".var/app/com.google.Chrome/cache/google-chrome"},
+	{"Chromium (flatpak)", ".var/app/org.chromium.Chromium/config/chromium", ".var/app/org.chromium.Chromium/cache/chromium"},
+	{"Brave (flatpak)", ".var/app/com.brave.Browser/config/BraveSoftware/Brave-Browser", ".var/app/com.brave.Browser/cache/BraveSoftware/Brave-Browser"},
+	{"Edge (flatpak)", ".var/app/com.microsoft.Edge/config/microsoft-edge", ".var/app/com.microsoft.Edge/cache/microsoft-edge"},
+	{"Vivaldi (flatpak)", ".var/app/com.vivaldi.Vivaldi/config/vivaldi", ".var/app/com.vivaldi.Vivaldi/cache/vivaldi"},
+	{"Opera (flatpak)", ".var/app/com.opera.Opera/config/opera", ".var/app/com.opera.Opera/cache/opera"},
+}
+
+var firefoxInstalls = []browserInstall{
+	{"Firefox", ".mozilla/firefox", ".cache/mozilla/firefox"},
+	{"Firefox (snap)", "snap/firefox/common/.mozilla/firefox", "snap/firefox/common/.cache/mozilla/firefox"},
+	{"Firefox (flatpak)", ".var/app/org.mozilla.firefox/.mozilla/firefox", ".var/app/org.mozilla.firefox/cache/mozilla/firefox"},
+}
+
+var thunderbirdInstalls = []browserInstall{
+	{"Thunderbird", ".thunderbird", ".cache/thunderbird"},
+	{"Thunderbird (snap)", "snap/thunderbird/common/.thunderbird", "snap/thunderbird/common/.cache/thunderbird"},
+	{"Thunderbird (flatpak)", ".var/app/org.mozilla.Thunderbird/.thunderbird", ".var/app/org.mozilla.Thunderbird/cache/thunderbird"},
+}
+
+var chromiumCacheDirs = []string{"Cache", "Code Cache", "GPUCache", "Service Worker/CacheStorage"}
+
+var chromiumProfilePattern = regexp.MustCompile(`^(Default|Profile \d+)$`)
+
+func chromiumProfiles(configDir, cacheDir string) []string {
+	var profiles []string
+	seen := make(map[string]bool)
+	for _, dir := range []string{configDir, cacheDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && chromiumProfilePattern.MatchString(entry.Name()) && !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				profiles = append(profiles, entry.Name())
+			}
+		}
+	}
+	if len(profiles) == 0 {
+		if _, err := os.Stat(filepath.Join(configDir, "Preferences")); err == nil {
+			profiles = append(profiles, ".")
+		}
+	}
+	return profiles
+}
+
+// chromiumRunningPid reads the pid from the SingletonLock symlink ("<hostname>-<pid>") of a user data dir.
+func chromiumRunningPid(userDataDir, proc string) (int, bool) {
+	if marker, ok := sandboxedAppMarker(userDataDir); ok {
+		pid := findProcessMentioning(proc, marker)
+		return pid, pid != 0
+	}
+
+	target, err := os.Readlink(filepath.Join(userDataDir, "SingletonLock"))
+	if err != nil {
+		return 0, false
+	}
+	i := strings.LastIndexByte(target, '-')
+	if i < 0 {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(target[i+1:])
+	if err != nil || !processAlive(proc, pid) {
+		return 0, false
+	}
+	return pid, true
+}
+
+// sandboxedAppMarker returns the install path (/app/<id>/ or /snap/<name>/) of the flatpak or snap owning dir.
+func sandboxedAppMarker(dir string) (string, bool) {
+	for _, sandbox := range []struct{ data, install string }{{"/.var/app/", "/app/"}, {"/snap/", "/snap/"}} {
+		_, rest, ok := strings.Cut(dir, sandbox.data)
+		if !ok {
+			continue
+		}
+		app, _, _ := strings.Cut(rest, "/")
+		if app != "" {
+			return sandbox.install + app + "/", true
+		}
+	}
+	return "", false
+}
+
+type firefoxProfile struct {
+	name     string
+	path     string
+	relative bool
+}
+
+// parseFirefoxProfiles returns the profiles listed in a profiles.ini file.
+func parseFirefoxProfiles(r io.Reader) []firefoxProfile {
+	var profiles []firefoxProfile
+	var current *firefoxProfile
+	flush := func() {
+		if current != nil && current.path != "" {
+			profiles = append(profiles, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			flush()
+			if strings.HasPrefix(line, "[Profile") {
+				current = &firefoxProfile{relative: true}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+		switch key {
+		case "Name":
+			current.name = value
+		case "Path":
+			current.path = value
+		case "IsRelative":
+			current.relative = value != "0"
+		}
+	}
+	flush()
+	return profiles
+}
+
+// firefoxRunningPid reads the pid from the lock symlink ("<ip>:+<pid>") of a profile.
+func firefoxRunningPid(profileDir, proc string) (int, bool) {
+	target, err := os.Readlink(filepath.Join(profileDir, "lock"))
+	if err != nil {
+		return 0, false
+	}
+	i := strings.LastIndexByte(target, '+')
+	if i < 0 {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(target[i+1:])
+	if err != nil || !processAlive(proc, pid) {
+		return 0, false
+	}
+	return pid, true
+}
+
+type browserProfile struct {
+	browser  string
+	name     string
+	dir      string
+	cacheDir string
+	firefox  bool
+	pid      int
+}
+
+func findBrowserProfiles(home, proc string) []browserProfile {
+	var profiles []browserProfile
+	for _, browser := range chromiumBrowsers {
+		configDir := filepath.Join(home, browser.config)
+		cacheDir := filepath.Join(home, browser.cache)
+		pid, _ := chromiumRunningPid(configDir, proc)
+		for _, name := range chromiumProfiles(configDir, cacheDir) {
+			profile := browserProfile{
+				browser:  browser.name,
+				name:     name,
+				dir:      filepath.Join(configDir, name),
+				cacheDir: filepath.Join(cacheDir, name),
+				pid:      pid,
+			}
+			if name == "." {
+				profile.name = "Default"
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return append(profiles, mozillaProfiles(home, proc, firefoxInstalls)...)
+}
+
+func mozillaProfiles(home, proc string, installs []browserInstall) []browserProfile {
+	var profiles []browserProfile
+	for _, browser := range installs {
+		configDir := filepath.Join(home, browser.config)
+		f, err := os.Open(filepath.Join(configDir, "profiles.ini"))
+		if err != nil {
+			continue
+		}
+		listed := parseFirefoxProfiles(f)
+		f.Close()
+
+		for _, p := range listed {
+			profile := browserProfile{browser: browser.name, name: p.name, dir: p.path, cacheDir: p.path, firefox: true}
+			if p.relative {
+				profile.dir = filepath.Join(configDir, p.path)
+				profile.cacheDir = filepath.Join(home, browser.cache, p.path)
+			}
+			profile.pid, _ = firefoxRunningPid(profile.dir, proc)
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+func clearBrowserCachesIn(home, proc string, d *deleter) {
+	for _, profile := range findBrowserProfiles(home, proc) {
+		if profile.pid != 0 {
+			fmt.Printf("Skipping %s profile %s in %s: browser is running (pid %d)\n", profile.browser, profile.name, home, profile.pid)
+			continue
+		}
+		before := d.freed
+		if profile.firefox {
+			d.removeContents(filepath.Join(profile.cacheDir, "cache2"))
+			d.removeContents(filepath.Join(profile.dir, "cache2"))
+		} else {
+			for _, base := range []string{profile.dir, profile.cacheDir} {
+				for _, cache := range chromiumCacheDirs {
+					d.removeContents(filepath.Join(base, cache))
+				}
+			}
+		}
+		fmt.Printf("%s profile %s in %s: %s freed\n", profile.browser, profile.name, home, utils.FormatBytes(uint64(d.freed-before)))
+	}
+}
@@ -0,0 +1,85 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFirefoxProfiles(t *testing.T) {
+	ini := `[Install4F96D1932A9F858E]
+Default=abcd1234.default-release
+Locked=1
+
+[Profile1]
+Name=portable
+IsRelative=0
+Path=/mnt/data/firefox-profile
+
+[Profile0]
+Name=default-release
+IsRelative=1
+Path=abcd1234.default-release
+Default=1
+
+[Profile2]
+Name=broken
+
+[General]
+StartWithLastProfile=1
+`
+	profiles := parseFirefoxProfiles(strings.NewReader(ini))
+	expected := []firefoxProfile{
+		{name: "portable", path: "/mnt/data/firefox-profile", relative: false},
+		{name: "default-release", path: "abcd1234.default-release", relative: true},
+	}
+	if len(profiles) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, profiles)
+	}
+	for i := range expected {
+		if profiles[i] != expected[i] {
+			t.Errorf("Profile %d: got %+v, want %+v", i, profiles[i], expected[i])
+		}
+	}
+}
+
+func TestChromiumRunningPid(t *testing.T) {
+	home := t.TempDir()
+	proc := t.TempDir()
+	for pid, cmdline := range map[string]string{
+		"10": "bwrap\x00--ro-bind\x00/var/lib/flatpak/app/org.chromium.Chromium/x86_64/stable/abc/files\x00/app\x00",
+		"20": "/snap/chromium/2890/usr/lib/chromium-browser/chrome\x00--type=renderer\x00",
+		"30": "/opt/google/chrome/chrome\x00",
+	} {
+		writeTestFile(t, filepath.Join(proc, pid, "cmdline"), cmdline)
+	}
+
+	// The SingletonLock of a sandboxed browser names a pid of its own PID
+	// namespace, which may belong to an unrelated process on the host.
+	for _, dir := range []string{".var/app/org.chromium.Chromium/config/chromium", ".var/app/com.google.Chrome/config/google-chrome", "snap/chromium/common/chromium", ".config/google-chrome"} {
+		if err := os.MkdirAll(filepath.Join(home, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink("my-host-30", filepath.Join(home, dir, "SingletonLock")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir     string
+		pid     int
+		running bool
+	}{
+		{".var/app/org.chromium.Chromium/config/chromium", 10, true},
+		{".var/app/com.google.Chrome/config/google-chrome", 0, false},
+		{"snap/chromium/common/chromium", 20, true},
+		{".config/google-chrome", 30, true},
+	}
+	for _, tt := range tests {
+		pid, running := chromiumRunningPid(filepath.Join(home, tt.dir), proc)
+		if pid != tt.pid || running != tt.running {
+			t.Errorf("chromiumRunningPid(%q) = %d, %v; want %d, %v", tt.dir, pid, running, tt.pid, tt.running)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/cosmix/broom/internal/utils"
 )
@@ -11,14 +12,48 @@ import (
 type Cleaner struct {
 	CleanupFunc          func() error
 	RequiresConfirmation bool
+	// Category groups cleaners that can be selected together with -i.
+	Category string
 }
 
+// CategoryPrivacy holds the cleaners erasing usage traces such as histories and cookies
+const CategoryPrivacy = "privacy"
+
+// Categories whose cleaners only run when included with -i.
+var optInCategories = map[string]bool{CategoryPrivacy: true}
+
 var cleanupFunctions sync.Map
 
+var preRunChecks []func() string
+
+// reclaimedBytes accumulates space freed outside of / or saved by compression.
+var reclaimedBytes atomic.Uint64
+
 func registerCleanup(name string, cleaner Cleaner) {
 	cleanupFunctions.Store(name, cleaner)
 }
 
+func registerPreRunCheck(check func() string) {
+	preRunChecks = append(preRunChecks, check)
+}
+
+func recordReclaimed(n int64) {
+	if n > 0 {
+		reclaimedBytes.Add(uint64(n))
+	}
+}
+
+// PreRunWarnings returns the warnings of all registered pre-run checks that fired
+func PreRunWarnings() []string {
+	var warnings []string
+	for _, check := range preRunChecks {
+		if warning := check(); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
 func GetAllCleanupTypes() []string {
 	var types []string
 	cleanupFunctions.Range(func(key, value interface{}) bool {
@@ -29,6 +64,31 @@ func GetAllCleanupTypes() []string {
 	return types
 }
 
+// GetDefaultCleanupTypes returns the cleanup types run by --all
+func GetDefaultCleanupTypes() []string {
+	var types []string
+	for _, t := range GetAllCleanupTypes() {
+		if cleaner, _ := GetCleaner(t); !optInCategories[cleaner.Category] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// GetCleanupTypesInCategory returns the cleanup types of a category
+func GetCleanupTypesInCategory(category string) []string {
+	if category == "" {
+		return nil
+	}
+	var types []string
+	for _, t := range GetAllCleanupTypes() {
+		if cleaner, _ := GetCleaner(t); cleaner.Category == category {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
 func GetCleaner(cleanupType string) (Cleaner, bool) {
 	cleanerInterface, ok := cleanupFunctions.Load(cleanupType)
 	if !ok {
@@ -39,6 +99,7 @@ func GetCleaner(cleanupType string) (Cleaner, bool) {
 
 func PerformCleanup(cleanupType string) (uint64, error) {
 	startSpace := utils.GetFreeDiskSpace()
+	reclaimedBytes.Store(0)
 
 	cleaner, ok := GetCleaner(cleanupType)
 	if !ok {
@@ -66,5 +127,8 @@ func PerformCleanup(cleanupType string) (uint64, error) {
 	} else {
 		spaceFreed = startSpace - endSpace
 	}
+	if reported := reclaimedBytes.Load(); reported > spaceFreed {
+		spaceFreed = reported
+	}
 	return spaceFreed, nil
 }
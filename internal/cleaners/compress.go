@@ -0,0 +1,113 @@
+package cleaners
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const (
+	logActionDelete   = "delete"
+	logActionCompress = "compress"
+)
+
+func preferredCompression(commandExists utils.CommandExistsFunc) string {
+	if commandExists("zstd") {
+		return ".zst"
+	}
+	return ".gz"
+}
+
+func isCompressedFile(path string) bool {
+	for _, suffix := range compressedLogSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressFile replaces path with path+suffix, keeping its ownership, mode and mtime.
+func compressFile(path, suffix string) (int64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	target := path + suffix
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return 0, err
+	}
+
+	switch suffix {
+	case ".gz":
+		err = writeGzip(dst, src, info)
+	case ".zst":
+		err = writeZstd(dst, src)
+	default:
+		err = fmt.Errorf("unsupported compression %s", suffix)
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return 0, err
+	}
+
+	if err := copyFileMetadata(target, info); err != nil {
+		os.Remove(target)
+		return 0, err
+	}
+
+	compressed, err := os.Stat(target)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		os.Remove(target)
+		return 0, err
+	}
+	return info.Size() - compressed.Size(), nil
+}
+
+func writeGzip(dst io.Writer, src io.Reader, info os.FileInfo) error {
+	gz := gzip.NewWriter(dst)
+	gz.Name = info.Name()
+	gz.ModTime = info.ModTime()
+	if _, err := io.Copy(gz, src); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZstd(dst io.Writer, src io.Reader) error {
+	return utils.Runner.RunWithIO("zstd -q -c", src, dst)
+}
+
+func copyFileMetadata(path string, info os.FileInfo) error {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Lchown(path, int(stat.Uid), int(stat.Gid)); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(path, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(path, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,133 @@
+package cleaners
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.2")
+	content := strings.Repeat("the same line over and over again\n", 1000)
+	if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := compressFile(path, ".gz")
+	if err != nil {
+		t.Fatalf("compressFile() error = %v", err)
+	}
+	if saved <= 0 {
+		t.Errorf("Expected compressFile() to save space, got %d", saved)
+	}
+
+	assertFilesRemoved(t, dir, "app.log.2")
+	info, err := os.Stat(path + ".gz")
+	if err != nil {
+		t.Fatalf("Expected compressed file: %v", err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("Expected mtime %v to be kept, got %v", mtime, info.ModTime())
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected permissions 0640 to be kept, got %o", info.Mode().Perm())
+	}
+
+	f, err := os.Open(path + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Error("Compressed content does not match the original")
+	}
+}
+
+func TestCompressFileZstd(t *testing.T) {
+	mock := setupTest()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("zstd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := compressFile(path, ".zst"); err != nil {
+		t.Fatalf("compressFile() error = %v", err)
+	}
+	if len(mock.Commands) != 1 || mock.Commands[0] != "zstd -q -c" {
+		t.Errorf("Unexpected commands: %v", mock.Commands)
+	}
+
+	assertFilesRemoved(t, dir, "app.log")
+	info, err := os.Stat(path + ".zst")
+	if err != nil {
+		t.Fatalf("Expected compressed file: %v", err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("Expected mtime %v to be kept, got %v", mtime, info.ModTime())
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600 to be kept, got %o", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path + ".zst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "zstd\n" {
+		t.Errorf("Unexpected compressed content %q", data)
+	}
+}
+
+func TestCompressFileZstdFailure(t *testing.T) {
+	mock := setupTest()
+	mock.RunWithIOFunc = func(string, io.Reader, io.Writer) error { return errors.New("zstd failed") }
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "app.log")
+
+	if _, err := compressFile(filepath.Join(dir, "app.log"), ".zst"); err == nil {
+		t.Error("Expected compressFile() to fail")
+	}
+	assertFilesExist(t, dir, "app.log")
+	assertFilesRemoved(t, dir, "app.log.zst")
+}
+
+func TestIsCompressedFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/var/log/syslog.2.gz", true},
+		{"/home/user/app.log.zst", true},
+		{"/home/user/app.log", false},
+	}
+
+	for _, tt := range tests {
+		if got := isCompressedFile(tt.path); got != tt.expected {
+			t.Errorf("isCompressedFile(%q) = %v; want %v", tt.path, got, tt.expected)
+		}
+	}
+}
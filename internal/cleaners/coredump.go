@@ -0,0 +1,155 @@
+package cleaners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type crashDump struct {
+	path       string
+	executable string
+	time       time.Time
+	companions []string
+}
+
+// parseCoredumpName parses core.<comm>.<uid>.<boot id>.<pid>.<usec>[.zst|.xz|.lz4].
+func parseCoredumpName(name string) (comm string, when time.Time, ok bool) {
+	rest, found := strings.CutPrefix(name, "core.")
+	if !found {
+		return "", time.Time{}, false
+	}
+	for _, suffix := range []string{".zst", ".xz", ".lz4"} {
+		rest = strings.TrimSuffix(rest, suffix)
+	}
+
+	// The command name may itself contain dots, so split from the right.
+	fields := strings.Split(rest, ".")
+	n := len(fields)
+	if n < 5 || len(fields[n-3]) != 32 {
+		return "", time.Time{}, false
+	}
+	usec, err := strconv.ParseInt(fields[n-1], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	for _, numeric := range []string{fields[n-4], fields[n-2]} {
+		if _, err := strconv.Atoi(numeric); err != nil {
+			return "", time.Time{}, false
+		}
+	}
+	return strings.Join(fields[:n-4], "."), time.UnixMicro(usec), true
+}
+
+func readApportExecutable(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "ExecutablePath: "); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func findCoredumps(dir string) []crashDump {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var dumps []crashDump
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		comm, when, ok := parseCoredumpName(entry.Name())
+		if !ok {
+			continue
+		}
+		dumps = append(dumps, crashDump{path: filepath.Join(dir, entry.Name()), executable: comm, time: when})
+	}
+	return dumps
+}
+
+func findApportReports(dir string) []crashDump {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var reports []crashDump
+	for _, entry := range entries {
+		base, ok := strings.CutSuffix(entry.Name(), ".crash")
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		report := crashDump{path: path, executable: readApportExecutable(path), time: info.ModTime()}
+		if report.executable == "" {
+			report.executable = entry.Name()
+		}
+		for _, suffix := range []string{".upload", ".uploaded"} {
+			companion := filepath.Join(dir, base+suffix)
+			if _, err := os.Lstat(companion); err == nil {
+				report.companions = append(report.companions, companion)
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func selectExpiredDumps(dumps []crashDump, keep int, maxAge time.Duration, now time.Time) (expired, kept []crashDump) {
+	sorted := append([]crashDump(nil), dumps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].time.After(sorted[j].time) })
+
+	seen := make(map[string]int)
+	for _, dump := range sorted {
+		rank := seen[dump.executable]
+		seen[dump.executable]++
+		if rank >= keep || (maxAge > 0 && now.Sub(dump.time) > maxAge) {
+			expired = append(expired, dump)
+		} else {
+			kept = append(kept, dump)
+		}
+	}
+	return expired, kept
+}
+
+func removeCrashReportsIn(crashDir, coredumpDir string, keep int, maxAge time.Duration, d *deleter) {
+	now := time.Now()
+	var kept []crashDump
+	for _, dumps := range [][]crashDump{findApportReports(crashDir), findCoredumps(coredumpDir)} {
+		expired, k := selectExpiredDumps(dumps, keep, maxAge, now)
+		for _, dump := range expired {
+			if d.removeFile(dump.path) {
+				for _, companion := range dump.companions {
+					d.removeFile(companion)
+				}
+			}
+		}
+		kept = append(kept, k...)
+	}
+
+	if len(kept) == 0 {
+		return
+	}
+	fmt.Printf("Kept %d crash dump(s):\n", len(kept))
+	for _, dump := range kept {
+		fmt.Printf("  %s  %s  %s\n", dump.time.Format("2006-01-02 15:04"), dump.executable, dump.path)
+	}
+}
@@ -0,0 +1,75 @@
+package cleaners
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCoredumpName(t *testing.T) {
+	tests := []struct {
+		name string
+		comm string
+		usec int64
+		ok   bool
+	}{
+		{"core.firefox.1000.0123456789abcdef0123456789abcdef.4242.1700000000123456.zst", "firefox", 1700000000123456, true},
+		{"core.python3.11.0.0123456789abcdef0123456789abcdef.7.1700000000000000", "python3.11", 1700000000000000, true},
+		{"core.app.1000.0123456789abcdef0123456789abcdef.7.1700000000000000.xz", "app", 1700000000000000, true},
+		{"core.1234", "", 0, false},
+		{"core.app.1000.shortbootid.7.1700000000000000", "", 0, false},
+		{"vmcore", "", 0, false},
+	}
+	for _, tt := range tests {
+		comm, when, ok := parseCoredumpName(tt.name)
+		if ok != tt.ok || comm != tt.comm || (ok && when.UnixMicro() != tt.usec) {
+			t.Errorf("parseCoredumpName(%q) = %q, %v, %v; want %q, %d, %v", tt.name, comm, when.UnixMicro(), ok, tt.comm, tt.usec, tt.ok)
+		}
+	}
+}
+
+func TestSelectExpiredDumps(t *testing.T) {
+	now := time.Now()
+	dump := func(exe string, age time.Duration) crashDump {
+		return crashDump{path: exe + age.String(), executable: exe, time: now.Add(-age)}
+	}
+	dumps := []crashDump{
+		dump("a", 3*time.Hour),
+		dump("a", time.Hour),
+		dump("a", 2*time.Hour),
+		dump("b", 40*24*time.Hour),
+		dump("c", time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		keep    int
+		maxAge  time.Duration
+		expired []string
+	}{
+		{"KeepNewest", 1, 0, []string{"a2h0m0s", "a3h0m0s"}},
+		{"KeepTwo", 2, 0, []string{"a3h0m0s"}},
+		{"MaxAge", 2, 30 * 24 * time.Hour, []string{"a3h0m0s", "b960h0m0s"}},
+		{"KeepNone", 0, 0, []string{"a1h0m0s", "c1h0m0s", "a2h0m0s", "a3h0m0s", "b960h0m0s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expired, kept := selectExpiredDumps(dumps, tt.keep, tt.maxAge, now)
+			if len(expired)+len(kept) != len(dumps) {
+				t.Fatalf("Expected every dump to be either expired or kept")
+			}
+			var got []string
+			for _, d := range expired {
+				got = append(got, d.path)
+			}
+			if len(got) != len(tt.expired) {
+				t.Fatalf("Expected expired %v, got %v", tt.expired, got)
+			}
+			for i := range got {
+				if got[i] != tt.expired[i] {
+					t.Errorf("Expected expired %v, got %v", tt.expired, got)
+					break
+				}
+			}
+		})
+	}
+}
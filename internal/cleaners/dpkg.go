@@ -0,0 +1,102 @@
+package cleaners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+const dpkgStatusFile = "/var/lib/dpkg/status"
+
+type dpkgPackage struct {
+	name      string
+	version   string
+	arch      string
+	status    string
+	priority  string
+	essential bool
+	conffiles []string
+}
+
+func (p dpkgPackage) qualifiedName() string {
+	if p.arch == "" || p.arch == "all" {
+		return p.name
+	}
+	return p.name + ":" + p.arch
+}
+
+func (p dpkgPackage) installed() bool {
+	return strings.HasSuffix(p.status, " installed")
+}
+
+// residualConfig reports the "rc" state of dpkg --list.
+func (p dpkgPackage) residualConfig() bool {
+	return strings.HasSuffix(p.status, " config-files")
+}
+
+func readDpkgStatus(path string) ([]dpkgPackage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseDpkgStatus(f)
+}
+
+func parseDpkgStatus(r io.Reader) ([]dpkgPackage, error) {
+	var packages []dpkgPackage
+	var current dpkgPackage
+	var field string
+
+	flush := func() {
+		if current.name != "" {
+			packages = append(packages, current)
+		}
+		current = dpkgPackage{}
+		field = ""
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if field == "Conffiles" {
+				if fields := strings.Fields(line); len(fields) > 0 {
+					current.conffiles = append(current.conffiles, fields[0])
+				}
+			}
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = name
+		value = strings.TrimSpace(value)
+		switch name {
+		case "Package":
+			current.name = value
+		case "Version":
+			current.version = value
+		case "Architecture":
+			current.arch = value
+		case "Status":
+			current.status = value
+		case "Priority":
+			current.priority = value
+		case "Essential":
+			current.essential = value == "yes"
+		}
+	}
+	flush()
+
+	return packages, scanner.Err()
+}
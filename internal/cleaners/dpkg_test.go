@@ -0,0 +1,69 @@
+package cleaners
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDpkgStatus = `Package: bash
+Essential: yes
+Status: install ok installed
+Priority: required
+Architecture: amd64
+Version: 5.2.15-2+b2
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+
+Package: old-daemon
+Status: deinstall ok config-files
+Priority: optional
+Architecture: amd64
+Version: 1.2-3
+Conffiles:
+ /etc/old-daemon/daemon.conf 0123456789abcdef0123456789abcdef
+ /etc/default/old-daemon fedcba9876543210fedcba9876543210 obsolete
+Description: a daemon that was removed
+
+Package: old-tool
+Status: deinstall ok config-files
+Priority: optional
+Architecture: all
+Version: 0.9
+
+Package: nano
+Status: install ok installed
+Priority: important
+Architecture: amd64
+Version: 7.2-1
+`
+
+func TestParseDpkgStatus(t *testing.T) {
+	packages, err := parseDpkgStatus(strings.NewReader(testDpkgStatus))
+	if err != nil {
+		t.Fatalf("parseDpkgStatus() error = %v", err)
+	}
+
+	if len(packages) != 4 {
+		t.Fatalf("Expected 4 packages, got %d", len(packages))
+	}
+
+	bash := packages[0]
+	if bash.name != "bash" || !bash.essential || bash.priority != "required" || !bash.installed() {
+		t.Errorf("Unexpected bash package: %+v", bash)
+	}
+
+	daemon := packages[1]
+	if !daemon.residualConfig() || daemon.installed() {
+		t.Errorf("Expected old-daemon to be in residual-config state: %+v", daemon)
+	}
+	if len(daemon.conffiles) != 2 || daemon.conffiles[1] != "/etc/default/old-daemon" {
+		t.Errorf("Unexpected conffiles for old-daemon: %v", daemon.conffiles)
+	}
+	if daemon.qualifiedName() != "old-daemon:amd64" {
+		t.Errorf("Unexpected qualified name: %s", daemon.qualifiedName())
+	}
+
+	if packages[2].qualifiedName() != "old-tool" {
+		t.Errorf("Expected architecture-independent package name to be unqualified, got %s", packages[2].qualifiedName())
+	}
+}
@@ -0,0 +1,88 @@
+package cleaners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+var electronCacheDirs = []string{"Cache", "Code Cache", "GPUCache", "CachedData", "Crashpad"}
+
+var electronMarkers = []string{"Local State", "Local Storage", "Session Storage", "Preferences"}
+
+var electronConfigRoots = []string{".config", ".var/app/*/config"}
+
+func isElectronAppDir(dir string) bool {
+	return anyExists(dir, electronMarkers) && anyExists(dir, electronCacheDirs)
+}
+
+func anyExists(dir string, names []string) bool {
+	for _, name := range names {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findElectronApps looks one or two levels deep, as in ~/.config/Microsoft/Microsoft Teams.
+func findElectronApps(home string) []string {
+	browsers := make(map[string]bool)
+	for _, browser := range chromiumBrowsers {
+		browsers[filepath.Join(home, browser.config)] = true
+	}
+
+	var apps []string
+	var scan func(dir string, depth int)
+	scan = func(dir string, depth int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() || browsers[path] {
+				continue
+			}
+			if isElectronAppDir(path) {
+				apps = append(apps, path)
+			} else if depth > 1 {
+				scan(path, depth-1)
+			}
+		}
+	}
+
+	for _, pattern := range electronConfigRoots {
+		roots, _ := filepath.Glob(filepath.Join(home, pattern))
+		for _, root := range roots {
+			scan(root, 2)
+		}
+	}
+	return apps
+}
+
+func cleanElectronCachesIn(home, proc string, d *deleter) {
+	for _, app := range findElectronApps(home) {
+		name, _ := filepath.Rel(home, app)
+		if pid, running := chromiumRunningPid(app, proc); running {
+			fmt.Printf("Skipping %s: app is running (pid %d)\n", name, pid)
+			continue
+		}
+		before := d.freed
+		for _, cache := range electronCacheDirs {
+			d.removeContents(filepath.Join(app, cache))
+		}
+		fmt.Printf("%s in %s: %s freed\n", name, home, utils.FormatBytes(uint64(d.freed-before)))
+	}
+}
+
+func cleanElectronCache() error {
+	d := newDeleter()
+	for _, home := range userHomes(passwdFile) {
+		cleanElectronCachesIn(home.dir, procDir, d)
+	}
+	d.report("Electron cache file(s)")
+	return nil
+}
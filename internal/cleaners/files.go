@@ -0,0 +1,184 @@
+package cleaners
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+func fileSize(path string) int64 {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func treeSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func lastUsed(info os.FileInfo) time.Time {
+	if atime := accessTime(info); atime.After(info.ModTime()) {
+		return atime
+	}
+	return info.ModTime()
+}
+
+// writeFileAtomic replaces path through a renamed temporary file, keeping the ownership and mode in info.
+func writeFileAtomic(path string, data []byte, info os.FileInfo) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Lchown(tmp.Name(), int(stat.Uid), int(stat.Gid)); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// configFragments orders drop-in files by name as systemd does, earlier dirs masking later ones.
+func configFragments(dirs []string, suffix string) []string {
+	files := make(map[string]string)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, suffix) {
+				continue
+			}
+			if _, masked := files[name]; !masked {
+				files[name] = filepath.Join(dir, name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, files[name])
+	}
+	return paths
+}
+
+func accessTime(info os.FileInfo) time.Time {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(stat.Atim.Sec, stat.Atim.Nsec)
+	}
+	return info.ModTime()
+}
+
+// deleter removes files on behalf of a cleaner, keeping those in use.
+type deleter struct {
+	open    map[string][]int
+	removed int
+	freed   int64
+	inUse   []string
+}
+
+func newDeleter() *deleter {
+	return &deleter{open: openFiles()}
+}
+
+func (d *deleter) removeFile(path string) bool {
+	if pids, ok := d.open[path]; ok {
+		d.inUse = append(d.inUse, fmt.Sprintf("%s (pid %d)", path, pids[0]))
+		return false
+	}
+	size := fileSize(path)
+	if err := os.Remove(path); err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Warning: Failed to remove %s: %v\n", path, err)
+		}
+		return false
+	}
+	d.removed++
+	d.freed += size
+	return true
+}
+
+func (d *deleter) removeTree(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	if !info.IsDir() {
+		return d.removeFile(path)
+	}
+	if !d.removeContents(path) {
+		return false
+	}
+	if err := os.Remove(path); err != nil {
+		fmt.Printf("Warning: Failed to remove %s: %v\n", path, err)
+		return false
+	}
+	return true
+}
+
+func (d *deleter) removeContents(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	empty := true
+	for _, entry := range entries {
+		if !d.removeTree(filepath.Join(dir, entry.Name())) {
+			empty = false
+		}
+	}
+	return empty
+}
+
+func (d *deleter) report(what string) {
+	recordReclaimed(d.freed)
+	fmt.Printf("Removed %d %s, %s freed\n", d.removed, what, utils.FormatBytes(uint64(d.freed)))
+	if len(d.inUse) == 0 {
+		return
+	}
+	fmt.Printf("Skipped %d file(s) in use by running processes:\n", len(d.inUse))
+	for _, path := range d.inUse {
+		fmt.Printf("  %s\n", path)
+	}
+}
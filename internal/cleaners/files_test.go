@@ -0,0 +1,38 @@
+package cleaners
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDeleterRemoveTree(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"tree/a/one",
+		"tree/a/two",
+		"tree/b/held",
+		"tree/b/c/three",
+		"other/four",
+	)
+
+	held := filepath.Join(dir, "tree/b/held")
+	d := &deleter{open: map[string][]int{held: {321}}}
+
+	if d.removeTree(filepath.Join(dir, "tree")) {
+		t.Error("Expected removeTree to report that a file in use was kept")
+	}
+	assertFilesRemoved(t, dir, "tree/a", "tree/b/c")
+	assertFilesExist(t, dir, "tree/b/held", "other/four")
+
+	if !d.removeTree(filepath.Join(dir, "other")) {
+		t.Error("Expected removeTree to remove a tree with no files in use")
+	}
+	assertFilesRemoved(t, dir, "other")
+
+	if d.removed != 4 {
+		t.Errorf("Expected 4 files removed, got %d", d.removed)
+	}
+	if len(d.inUse) != 1 || d.inUse[0] != held+" (pid 321)" {
+		t.Errorf("Unexpected in-use report: %v", d.inUse)
+	}
+}
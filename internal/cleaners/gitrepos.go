@@ -0,0 +1,109 @@
+package cleaners
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+type gitRepo struct {
+	dir    string
+	gitDir string
+	uid    int
+}
+
+func findGitRepos(root string) []gitRepo {
+	var repos []gitRepo
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		gitDir := filepath.Join(path, ".git")
+		info, err := os.Lstat(gitDir)
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		repo := gitRepo{dir: path, gitDir: gitDir}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			repo.uid = int(stat.Uid)
+		}
+		repos = append(repos, repo)
+		return nil
+	})
+	return repos
+}
+
+// gitCommand runs git as the repository owner so that new objects and packs keep their ownership.
+func gitCommand(repo gitRepo, owners map[int]string, args string) (string, bool) {
+	return runAsOwner(repo.uid, owners, fmt.Sprintf("git -C %s %s", shellQuote(repo.dir), args))
+}
+
+func usesGitLFS(repo gitRepo) bool {
+	info, err := os.Stat(filepath.Join(repo.gitDir, "lfs"))
+	return err == nil && info.IsDir()
+}
+
+func maintainGitRepos(repos []gitRepo, owners map[int]string) {
+	var total int64
+	maintained := 0
+	for _, repo := range repos {
+		if _, err := os.Stat(filepath.Join(repo.gitDir, "index.lock")); err == nil {
+			fmt.Printf("Skipping %s: a git operation is in progress\n", repo.dir)
+			continue
+		}
+		if _, ok := gitCommand(repo, owners, ""); !ok {
+			fmt.Printf("Skipping %s: owned by unknown uid %d\n", repo.dir, repo.uid)
+			continue
+		}
+
+		steps := []string{
+			"worktree prune",
+			"reflog expire --expire=90.days.ago --expire-unreachable=30.days.ago --all",
+			"gc --quiet --prune",
+		}
+
+		maintained++
+		before := treeSize(repo.gitDir)
+		for _, step := range steps {
+			command, _ := gitCommand(repo, owners, step)
+			if err := utils.Runner.RunWithIndicator(command, fmt.Sprintf("Running git %s in %s...", strings.Fields(step)[0], repo.dir)); err != nil {
+				fmt.Printf("Warning: git %s failed in %s: %v\n", step, repo.dir, err)
+			}
+		}
+		after := treeSize(repo.gitDir)
+		freed := max(before-after, 0)
+		total += freed
+		fmt.Printf("%s: .git %s -> %s\n", repo.dir, utils.FormatBytes(uint64(before)), utils.FormatBytes(uint64(after)))
+	}
+	recordReclaimed(total)
+	fmt.Printf("Maintained %d git repositories, %s freed\n", maintained, utils.FormatBytes(uint64(total)))
+}
+
+func homeGitRepos() ([]gitRepo, map[int]string) {
+	var repos []gitRepo
+	homes := userHomes(passwdFile)
+	for _, home := range homes {
+		repos = append(repos, findGitRepos(home.dir)...)
+	}
+	return repos, userNames(homes)
+}
+
+func cleanGitRepos(commandExists utils.CommandExistsFunc) func() error {
+	return func() error {
+		if !commandExists("git") {
+			fmt.Println("Git cleanup: Skipped (not installed)")
+			return nil
+		}
+		repos, owners := homeGitRepos()
+		maintainGitRepos(repos, owners)
+		return nil
+	}
+}
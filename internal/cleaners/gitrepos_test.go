@@ -0,0 +1,90 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindGitRepos(t *testing.T) {
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"src/app/.git/HEAD",
+		"src/app/vendor/lib/.git/HEAD",
+		"src/worktree/.git",
+		"src/notes/todo.txt",
+		".cache/plugin/.git/HEAD",
+	)
+
+	repos := findGitRepos(home)
+	var dirs []string
+	for _, repo := range repos {
+		dirs = append(dirs, repo.dir)
+	}
+	expected := []string{filepath.Join(home, "src/app"), filepath.Join(home, "src/app/vendor/lib")}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("Expected %v, got %v", expected, dirs)
+	}
+}
+
+func TestMaintainGitRepos(t *testing.T) {
+	mock := setupTest()
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"app/.git/HEAD",
+		"app/.git/lfs/objects/ab/cd/abcd",
+		"app/.git/objects/12/3456",
+		"busy/.git/HEAD",
+		"busy/.git/index.lock",
+		"foreign/.git/HEAD",
+	)
+	app := filepath.Join(home, "app")
+	// Simulate gc packing the loose object.
+	mock.RunWithIndicatorFunc = func(command, message string) error {
+		os.RemoveAll(filepath.Join(app, ".git/objects/12"))
+		return nil
+	}
+
+	repos := findGitRepos(home)
+	for i := range repos {
+		repos[i].uid = 1000
+		if filepath.Base(repos[i].dir) == "foreign" {
+			repos[i].uid = 1234
+		}
+	}
+	maintainGitRepos(repos, map[int]string{1000: "alice"})
+
+	// LFS objects are pruned by the git_lfs cleaner only.
+	prefix := "runuser -u 'alice' -- git -C '" + app + "' "
+	expected := []string{
+		prefix + "worktree prune",
+		prefix + "reflog expire --expire=90.days.ago --expire-unreachable=30.days.ago --all",
+		prefix + "gc --quiet --prune",
+	}
+	if !reflect.DeepEqual(mock.Commands, expected) {
+		t.Errorf("Expected %v, got %v", expected, mock.Commands)
+	}
+	assertFilesRemoved(t, app, ".git/objects/12")
+}
+
+func TestGitCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		uid      int
+		expected string
+		ok       bool
+	}{
+		{"Root", 0, "git -C '/srv/repo' gc", true},
+		{"KnownOwner", 1000, "runuser -u 'alice' -- git -C '/srv/repo' gc", true},
+		{"UnknownOwner", 1234, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := gitCommand(gitRepo{dir: "/srv/repo", uid: tt.uid}, map[int]string{1000: "alice"}, "gc")
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("Expected %q, %v, got %q, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package cleaners
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+var jetbrainsRoots = []struct {
+	path   string
+	config bool
+}{
+	{".cache/JetBrains", false},
+	{".local/share/JetBrains", false},
+	{".config/JetBrains", true},
+}
+
+// The dataDirectoryName in product-info.json names the directories of an installed IDE.
+var (
+	jetbrainsInstallPatterns = []string{
+		"/opt/*/product-info.json",
+		"/opt/*/*/product-info.json",
+		"/usr/share/*/product-info.json",
+		"/snap/*/current/product-info.json",
+	}
+	jetbrainsToolboxPatterns = []string{
+		".local/share/JetBrains/Toolbox/apps/*/product-info.json",
+		".local/share/JetBrains/Toolbox/apps/*/*/*/product-info.json",
+	}
+)
+
+// Product directories such as PyCharm2021.1 or IntelliJIdea2022.3.
+var jetbrainsDirPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*?)(\d{4}\.\d+)$`)
+
+type jetbrainsVersion struct {
+	product string
+	version string
+	dirs    []string
+	configs []string
+}
+
+func (v jetbrainsVersion) name() string {
+	return v.product + v.version
+}
+
+func installedJetBrainsVersions(patterns []string) map[string]bool {
+	installed := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			var info struct {
+				DataDirectoryName string `json:"dataDirectoryName"`
+			}
+			if json.Unmarshal(data, &info) == nil && info.DataDirectoryName != "" {
+				installed[info.DataDirectoryName] = true
+			}
+		}
+	}
+	return installed
+}
+
+func findJetBrainsVersions(home string) []jetbrainsVersion {
+	byName := make(map[string]*jetbrainsVersion)
+	for _, root := range jetbrainsRoots {
+		dir := filepath.Join(home, root.path)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			m := jetbrainsDirPattern.FindStringSubmatch(entry.Name())
+			if m == nil || !entry.IsDir() {
+				continue
+			}
+			v, ok := byName[entry.Name()]
+			if !ok {
+				v = &jetbrainsVersion{product: m[1], version: m[2]}
+				byName[entry.Name()] = v
+			}
+			if root.config {
+				v.configs = append(v.configs, filepath.Join(dir, entry.Name()))
+			} else {
+				v.dirs = append(v.dirs, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	versions := make([]jetbrainsVersion, 0, len(byName))
+	for _, v := range byName {
+		versions = append(versions, *v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].product != versions[j].product {
+			return versions[i].product < versions[j].product
+		}
+		return compareVersions(versions[i].version, versions[j].version) < 0
+	})
+	return versions
+}
+
+func selectOldJetBrainsVersions(versions []jetbrainsVersion, installed map[string]bool) []jetbrainsVersion {
+	newest := make(map[string]string)
+	for _, v := range versions {
+		if current, ok := newest[v.product]; !ok || compareVersions(v.version, current) > 0 {
+			newest[v.product] = v.version
+		}
+	}
+	var old []jetbrainsVersion
+	for _, v := range versions {
+		if v.version != newest[v.product] && !installed[v.name()] {
+			old = append(old, v)
+		}
+	}
+	return old
+}
+
+func cleanJetBrainsIn(home string, installed map[string]bool, removeConfigs bool, d *deleter) {
+	versions := findJetBrainsVersions(home)
+	old := selectOldJetBrainsVersions(versions, installed)
+	for _, v := range versions {
+		if installed[v.name()] {
+			fmt.Printf("Keeping %s in %s (installed)\n", v.name(), home)
+		} else if !slices.ContainsFunc(old, func(o jetbrainsVersion) bool { return o.name() == v.name() }) {
+			fmt.Printf("Keeping %s in %s (newest)\n", v.name(), home)
+		}
+	}
+	for _, v := range old {
+		dirs := v.dirs
+		if removeConfigs {
+			dirs = append(dirs, v.configs...)
+		}
+		var size int64
+		for _, dir := range dirs {
+			size += treeSize(dir)
+		}
+		note := ""
+		if len(v.configs) > 0 && !removeConfigs {
+			note = " (config kept)"
+		}
+		fmt.Printf("Removing %s in %s: %s%s\n", v.name(), home, utils.FormatBytes(uint64(size)), note)
+		for _, dir := range dirs {
+			d.removeTree(dir)
+		}
+	}
+
+	filepath.WalkDir(filepath.Join(home, ".local/share/JetBrains"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		switch entry.Name() {
+		case ".caches":
+			d.removeTree(path)
+			return filepath.SkipDir
+		case "Toolbox":
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+func cleanJetBrainsIDECaches() func() error {
+	return func() error {
+		d := newDeleter()
+		for _, home := range userHomes(passwdFile) {
+			patterns := append([]string{}, jetbrainsInstallPatterns...)
+			for _, pattern := range jetbrainsToolboxPatterns {
+				patterns = append(patterns, filepath.Join(home.dir, pattern))
+			}
+			cleanJetBrainsIn(home.dir, installedJetBrainsVersions(patterns), options.JetBrainsRemoveConfigs, d)
+		}
+		d.report("JetBrains file(s)")
+		return nil
+	}
+}
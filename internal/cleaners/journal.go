@@ -0,0 +1,325 @@
+package cleaners
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const (
+	journaldConfFile = "/etc/systemd/journald.conf"
+	journalDir       = "/var/log/journal"
+	// Size the journal is vacuumed to when neither the command line nor
+	// journald.conf sets a limit.
+	defaultJournalMaxUse = "100M"
+	// Units are flagged, largest first, until they account for this share of
+	// the journal volume.
+	journalNoisyShare = 0.5
+)
+
+var journaldConfDirs = []string{"/etc/systemd/journald.conf.d", "/run/systemd/journald.conf.d", "/usr/lib/systemd/journald.conf.d"}
+
+type journaldConfig struct {
+	systemMaxUse   string
+	systemKeepFree string
+	maxRetention   string
+	systemMaxFiles string
+}
+
+func parseJournaldConfig(r io.Reader, cfg *journaldConfig) {
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != "Journal" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "SystemMaxUse":
+			cfg.systemMaxUse = value
+		case "SystemKeepFree":
+			cfg.systemKeepFree = value
+		case "MaxRetentionSec":
+			cfg.maxRetention = value
+		case "SystemMaxFiles":
+			cfg.systemMaxFiles = value
+		}
+	}
+}
+
+func loadJournaldConfig(confFile string, confDirs []string) journaldConfig {
+	var cfg journaldConfig
+	for _, path := range append([]string{confFile}, configFragments(confDirs, ".conf")...) {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		parseJournaldConfig(f, &cfg)
+		f.Close()
+	}
+	return cfg
+}
+
+// journalVacuumArgs prefers the command line limits, then journald.conf, then defaultJournalMaxUse.
+func journalVacuumArgs(cfg journaldConfig, opts Options) []string {
+	size, age, files := opts.JournalVacuumSize, opts.JournalVacuumTime, ""
+	if opts.JournalVacuumFiles > 0 {
+		files = strconv.Itoa(opts.JournalVacuumFiles)
+	}
+	if size == "" && age == "" && files == "" {
+		size, age, files = cfg.systemMaxUse, cfg.maxRetention, cfg.systemMaxFiles
+	}
+	if size == "" && age == "" && files == "" {
+		size = defaultJournalMaxUse
+	}
+
+	var args []string
+	if size != "" {
+		args = append(args, "--vacuum-size="+size)
+	}
+	if age != "" {
+		args = append(args, "--vacuum-time="+age)
+	}
+	if files != "" {
+		args = append(args, "--vacuum-files="+files)
+	}
+	return args
+}
+
+// Offset and length of the tail entry boot id in a journal file header.
+const (
+	journalBootIDOffset = 56
+	journalBootIDLen    = 16
+)
+
+func journalFileBootID(path string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	header := make([]byte, journalBootIDOffset+journalBootIDLen)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:8]) != "LPKSHHRH" {
+		return "", false
+	}
+	return hex.EncodeToString(header[journalBootIDOffset:]), true
+}
+
+func journalBootUsage(dir string) map[string]int64 {
+	usage := make(map[string]int64)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".journal") && !strings.HasSuffix(path, ".journal~") {
+			return nil
+		}
+		bootID, ok := journalFileBootID(path)
+		if !ok {
+			bootID = "unknown"
+		}
+		usage[bootID] += fileSize(path)
+		return nil
+	})
+	return usage
+}
+
+func parseJournalBoots(output string) map[string]int {
+	boots := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		idx, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		boots[strings.ReplaceAll(fields[1], "-", "")] = idx
+	}
+	return boots
+}
+
+type unitUsage struct {
+	unit    string
+	entries int
+	bytes   int64
+}
+
+// unitUsageCounter tallies `journalctl -o json` output by unit as it is written, line by line.
+type unitUsageCounter struct {
+	byUnit  map[string]*unitUsage
+	partial []byte
+}
+
+func newUnitUsageCounter() *unitUsageCounter {
+	return &unitUsageCounter{byUnit: make(map[string]*unitUsage)}
+}
+
+func (c *unitUsageCounter) Write(p []byte) (int, error) {
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			c.partial = append(c.partial, data...)
+			return len(p), nil
+		}
+		if len(c.partial) > 0 {
+			c.add(append(c.partial, data[:i]...))
+			c.partial = c.partial[:0]
+		} else {
+			c.add(data[:i])
+		}
+		data = data[i+1:]
+	}
+}
+
+func (c *unitUsageCounter) add(line []byte) {
+	var entry map[string]json.RawMessage
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return
+	}
+	unit := "unknown"
+	for _, field := range []string{"_SYSTEMD_UNIT", "_SYSTEMD_USER_UNIT", "SYSLOG_IDENTIFIER"} {
+		var value string
+		if json.Unmarshal(entry[field], &value) == nil && value != "" {
+			unit = value
+			break
+		}
+	}
+	u, ok := c.byUnit[unit]
+	if !ok {
+		u = &unitUsage{unit: unit}
+		c.byUnit[unit] = u
+	}
+	u.entries++
+	u.bytes += int64(len(entry["MESSAGE"]))
+}
+
+func (c *unitUsageCounter) units() []unitUsage {
+	if len(c.partial) > 0 {
+		c.add(c.partial)
+		c.partial = nil
+	}
+	units := make([]unitUsage, 0, len(c.byUnit))
+	for _, u := range c.byUnit {
+		units = append(units, *u)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].bytes != units[j].bytes {
+			return units[i].bytes > units[j].bytes
+		}
+		return units[i].unit < units[j].unit
+	})
+	return units
+}
+
+func noisyUnits(units []unitUsage) int {
+	var total, sum int64
+	for _, u := range units {
+		total += u.bytes
+	}
+	for i, u := range units {
+		sum += u.bytes
+		if float64(sum) >= journalNoisyShare*float64(total) {
+			return i + 1
+		}
+	}
+	return len(units)
+}
+
+func cleanJournalLogs() error {
+	return cleanJournalLogsWith(journaldConfFile, journaldConfDirs, journalDir)
+}
+
+func cleanJournalLogsWith(confFile string, confDirs []string, dir string) error {
+	cfg := loadJournaldConfig(confFile, confDirs)
+	if cfg.systemMaxUse != "" || cfg.maxRetention != "" || cfg.systemMaxFiles != "" || cfg.systemKeepFree != "" {
+		fmt.Printf("journald.conf: SystemMaxUse=%s SystemKeepFree=%s MaxRetentionSec=%s SystemMaxFiles=%s\n",
+			orDash(cfg.systemMaxUse), orDash(cfg.systemKeepFree), orDash(cfg.maxRetention), orDash(cfg.systemMaxFiles))
+	}
+
+	printJournalBootUsage(dir)
+	printJournalUnitUsage()
+
+	args := journalVacuumArgs(cfg, options)
+	return utils.Runner.RunWithIndicator("journalctl "+strings.Join(args, " "), "Vacuuming journal logs...")
+}
+
+func printJournalBootUsage(dir string) {
+	usage := journalBootUsage(dir)
+	if len(usage) == 0 {
+		return
+	}
+	output, _ := utils.Runner.RunWithOutput("journalctl --list-boots --no-pager")
+	boots := parseJournalBoots(output)
+
+	ids := make([]string, 0, len(usage))
+	for id := range usage {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return usage[ids[i]] > usage[ids[j]] })
+
+	fmt.Println("Journal usage per boot:")
+	for _, id := range ids {
+		label := "?"
+		if idx, ok := boots[id]; ok {
+			label = strconv.Itoa(idx)
+		}
+		fmt.Printf("  %4s  %s  %s\n", label, id, utils.FormatBytes(uint64(usage[id])))
+	}
+}
+
+func printJournalUnitUsage() {
+	counter := newUnitUsageCounter()
+	err := utils.Runner.RunWithIO("journalctl -b --no-pager -o json --output-fields=_SYSTEMD_UNIT,_SYSTEMD_USER_UNIT,SYSLOG_IDENTIFIER,MESSAGE", nil, counter)
+	if err != nil {
+		fmt.Printf("Warning: Unable to read journal entries: %v\n", err)
+		return
+	}
+	units := counter.units()
+	if len(units) == 0 {
+		return
+	}
+
+	noisy := noisyUnits(units)
+	fmt.Println("Journal volume per unit (current boot):")
+	for i, u := range units {
+		if i >= 10 {
+			break
+		}
+		flag := ""
+		if i < noisy {
+			flag = "  <- top contributor"
+		}
+		fmt.Printf("  %-40s %8d entries  %s%s\n", u.unit, u.entries, utils.FormatBytes(uint64(u.bytes)), flag)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadJournaldConfig(t *testing.T) {
+	dir := t.TempDir()
+	confFile := filepath.Join(dir, "journald.conf")
+	etcDir := filepath.Join(dir, "etc")
+	libDir := filepath.Join(dir, "lib")
+	writeTestFile(t, confFile, "[Journal]\n#SystemMaxUse=\nSystemMaxUse=1G\nSystemKeepFree=2G\n[Other]\nMaxRetentionSec=1y\n")
+	writeTestFile(t, filepath.Join(libDir, "10-size.conf"), "[Journal]\nSystemMaxUse=800M\n")
+	writeTestFile(t, filepath.Join(etcDir, "10-size.conf"), "[Journal]\nSystemMaxUse=300M\n")
+	writeTestFile(t, filepath.Join(libDir, "20-retention.conf"), "[Journal]\nMaxRetentionSec=2week\n")
+
+	cfg := loadJournaldConfig(confFile, []string{etcDir, libDir})
+	expected := journaldConfig{systemMaxUse: "300M", systemKeepFree: "2G", maxRetention: "2week"}
+	if cfg != expected {
+		t.Errorf("Got %+v, want %+v", cfg, expected)
+	}
+}
+
+func TestJournalBootUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeJournal := func(name string, bootID byte, size int) {
+		data := make([]byte, size)
+		copy(data, "LPKSHHRH")
+		for i := 0; i < journalBootIDLen; i++ {
+			data[journalBootIDOffset+i] = bootID
+		}
+		path := filepath.Join(dir, "machine", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeJournal("system.journal", 0xaa, 4096)
+	writeJournal("system@0001-0002-0003.journal", 0xbb, 1024)
+	writeJournal("user-1000.journal", 0xaa, 2048)
+	writeTestFiles(t, dir, "machine/notes.txt")
+
+	usage := journalBootUsage(dir)
+	current := strings.Repeat("aa", journalBootIDLen)
+	previous := strings.Repeat("bb", journalBootIDLen)
+	if len(usage) != 2 || usage[current] != 6144 || usage[previous] != 1024 {
+		t.Errorf("Unexpected usage per boot: %v", usage)
+	}
+
+	boots := parseJournalBoots("IDX BOOT ID                          FIRST ENTRY                 LAST ENTRY\n" +
+		" -1 " + previous + " Mon 2024-01-01 10:00:00 UTC Mon 2024-01-01 18:00:00 UTC\n" +
+		"  0 " + current + " Tue 2024-01-02 09:00:00 UTC Tue 2024-01-02 12:00:00 UTC\n")
+	if boots[current] != 0 || boots[previous] != -1 || len(boots) != 2 {
+		t.Errorf("Unexpected boots: %v", boots)
+	}
+}
+
+func TestUnitUsageCounter(t *testing.T) {
+	output := `{"_SYSTEMD_UNIT":"noisy.service","MESSAGE":"` + strings.Repeat("x", 100) + `"}
+{"_SYSTEMD_UNIT":"noisy.service","MESSAGE":"` + strings.Repeat("x", 100) + `"}
+{"_SYSTEMD_UNIT":"quiet.service","MESSAGE":"hello"}
+{"SYSLOG_IDENTIFIER":"kernel","MESSAGE":"` + strings.Repeat("k", 50) + `"}
+{"_SYSTEMD_UNIT":"binary.service","MESSAGE":[1,2,3]}
+not json
+`
+	// Write the output in small chunks, as a pipe delivers it, splitting
+	// lines between writes.
+	counter := newUnitUsageCounter()
+	for chunk := range slices.Chunk([]byte(output), 7) {
+		if _, err := counter.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	units := counter.units()
+	if len(units) != 4 {
+		t.Fatalf("Expected 4 units, got %+v", units)
+	}
+	if units[0].unit != "noisy.service" || units[0].entries != 2 || units[0].bytes != 204 {
+		t.Errorf("Unexpected top unit: %+v", units[0])
+	}
+	if units[1].unit != "kernel" {
+		t.Errorf("Expected kernel messages to be attributed to their syslog identifier, got %+v", units[1])
+	}
+	if n := noisyUnits(units); n != 1 {
+		t.Errorf("Expected 1 noisy unit, got %d", n)
+	}
+}
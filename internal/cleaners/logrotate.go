@@ -0,0 +1,202 @@
+package cleaners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	logrotateConfFile = "/etc/logrotate.conf"
+	logrotateConfDir  = "/etc/logrotate.d"
+
+	// defaultLogrotateDateformat is the dateformat logrotate uses with dateext.
+	defaultLogrotateDateformat = "-%Y%m%d"
+)
+
+type logrotateRule struct {
+	patterns      []string
+	rotate        int
+	compress      bool
+	delaycompress bool
+	dateext       bool
+	dateformat    string
+}
+
+var logrotateScriptDirectives = map[string]bool{
+	"postrotate": true, "prerotate": true, "firstaction": true, "lastaction": true, "preremove": true,
+}
+
+func parseLogrotateConfig(r io.Reader, defaults logrotateRule) (logrotateRule, []logrotateRule) {
+	defaults.patterns = nil
+	var rules []logrotateRule
+	var pending []string
+	var current *logrotateRule
+	inScript := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if inScript {
+			if line == "endscript" {
+				inScript = false
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if current == nil {
+			head, _, opens := strings.Cut(line, "{")
+			pending = append(pending, strings.Fields(head)...)
+			if !opens {
+				// Outside a block, a line is either a global directive or the
+				// start of a pattern list continued on the next lines.
+				if len(pending) > 0 && !strings.HasPrefix(pending[0], "/") && !strings.HasPrefix(pending[0], "\"") {
+					applyLogrotateDirective(&defaults, pending)
+					pending = nil
+				}
+				continue
+			}
+			current = &logrotateRule{rotate: defaults.rotate, compress: defaults.compress, delaycompress: defaults.delaycompress, dateext: defaults.dateext, dateformat: defaults.dateformat}
+			for _, p := range pending {
+				current.patterns = append(current.patterns, strings.Trim(p, "\""))
+			}
+			pending = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, "}") {
+			rules = append(rules, *current)
+			current = nil
+			continue
+		}
+		fields := strings.Fields(line)
+		if logrotateScriptDirectives[fields[0]] {
+			inScript = true
+			continue
+		}
+		applyLogrotateDirective(current, fields)
+	}
+
+	return defaults, rules
+}
+
+func applyLogrotateDirective(rule *logrotateRule, fields []string) {
+	switch fields[0] {
+	case "rotate":
+		if len(fields) > 1 {
+			if n, err := strconv.Atoi(fields[1]); err == nil {
+				rule.rotate = n
+			}
+		}
+	case "compress":
+		rule.compress = true
+	case "nocompress":
+		rule.compress = false
+	case "delaycompress":
+		rule.delaycompress = true
+	case "nodelaycompress":
+		rule.delaycompress = false
+	case "dateext":
+		rule.dateext = true
+	case "nodateext":
+		rule.dateext = false
+	case "dateformat":
+		if len(fields) > 1 {
+			rule.dateformat = fields[1]
+		}
+	}
+}
+
+func loadLogrotateRules(confFile, confDir string) []logrotateRule {
+	defaults := logrotateRule{rotate: -1}
+	var rules []logrotateRule
+	if f, err := os.Open(confFile); err == nil {
+		defaults, rules = parseLogrotateConfig(f, defaults)
+		f.Close()
+	}
+	return append(rules, loadLogrotateDir(confDir, defaults)...)
+}
+
+func loadLogrotateDir(dir string, defaults logrotateRule) []logrotateRule {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var all []logrotateRule
+	for _, entry := range entries {
+		name := entry.Name()
+		// logrotate skips package manager leftovers and backups.
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || strings.Contains(name, ".dpkg-") || strings.HasSuffix(name, ".rpmsave") || strings.HasSuffix(name, ".rpmnew") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		_, rules := parseLogrotateConfig(f, defaults)
+		f.Close()
+		all = append(all, rules...)
+	}
+	return all
+}
+
+func (r logrotateRule) matches(path string) bool {
+	for _, pattern := range r.patterns {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// retention keeps none when the rule sets no rotate count, as logrotate does.
+func (r logrotateRule) retention(limit int) int {
+	keep := max(r.rotate, 0)
+	if limit > 0 {
+		keep = min(keep, limit)
+	}
+	return keep
+}
+
+// generationPattern matches the rotated names of a log under the rule, capturing the live log.
+func (r logrotateRule) generationPattern() *regexp.Regexp {
+	suffix := `\.(?:0|[1-9]\d*)`
+	if r.dateext {
+		format := r.dateformat
+		if format == "" {
+			format = defaultLogrotateDateformat
+		}
+		suffix = "(?:" + suffix + "|" + strftimePattern(format) + ")"
+	}
+	return regexp.MustCompile(`^(.+?)` + suffix + `$`)
+}
+
+func strftimePattern(format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteString(regexp.QuoteMeta(format[i : i+1]))
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			b.WriteString(`\d{4}`)
+		case 'm', 'd', 'H', 'M', 'S', 'V':
+			b.WriteString(`\d{2}`)
+		case 's':
+			b.WriteString(`\d+`)
+		default:
+			b.WriteString(regexp.QuoteMeta(format[i : i+1]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testLogrotateConf = `# see "man logrotate" for details
+weekly
+rotate 4
+create
+compress
+
+include /etc/logrotate.d
+
+/var/log/wtmp {
+    missingok
+    monthly
+    rotate 1
+}
+`
+
+const testLogrotateRsyslog = `/var/log/syslog
+/var/log/mail.log
+/var/log/auth.log
+{
+	rotate 4
+	weekly
+	missingok
+	notifempty
+	delaycompress
+	sharedscripts
+	postrotate
+		/usr/lib/rsyslog/rsyslog-rotate
+	endscript
+}
+
+"/var/log/app/*.log" {
+	nocompress
+	dateext
+	dateformat .%Y-%m-%d
+}
+`
+
+func TestParseLogrotateConfig(t *testing.T) {
+	defaults, rules := parseLogrotateConfig(strings.NewReader(testLogrotateConf), logrotateRule{rotate: -1})
+	if defaults.rotate != 4 || !defaults.compress {
+		t.Errorf("Unexpected global defaults: %+v", defaults)
+	}
+	if len(rules) != 1 || rules[0].patterns[0] != "/var/log/wtmp" || rules[0].rotate != 1 {
+		t.Fatalf("Unexpected rules: %+v", rules)
+	}
+
+	_, rules = parseLogrotateConfig(strings.NewReader(testLogrotateRsyslog), defaults)
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(rules))
+	}
+	if len(rules[0].patterns) != 3 || rules[0].patterns[2] != "/var/log/auth.log" {
+		t.Errorf("Unexpected patterns: %v", rules[0].patterns)
+	}
+	if !rules[0].compress || !rules[0].delaycompress || rules[0].rotate != 4 {
+		t.Errorf("Expected rsyslog rule to inherit compress and set delaycompress: %+v", rules[0])
+	}
+	if rules[1].compress {
+		t.Errorf("Expected nocompress to override the global compress: %+v", rules[1])
+	}
+	if rules[0].dateext || !rules[1].dateext || rules[1].dateformat != ".%Y-%m-%d" {
+		t.Errorf("Expected dateext and dateformat on the app rule only: %+v", rules)
+	}
+
+	if rules[0].matches("/var/log/app/server.log") || !rules[1].matches("/var/log/app/server.log") {
+		t.Error("Expected /var/log/app/server.log to match the app rule only")
+	}
+	if rules[0].matches("/var/log/kern.log") || rules[1].matches("/var/log/kern.log") {
+		t.Error("Expected /var/log/kern.log not to match any rule")
+	}
+}
+
+func TestLoadLogrotateRules(t *testing.T) {
+	dir := t.TempDir()
+	confFile := filepath.Join(dir, "logrotate.conf")
+	confDir := filepath.Join(dir, "logrotate.d")
+	writeTestFile(t, confFile, testLogrotateConf)
+	if err := os.Mkdir(confDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(confDir, "rsyslog"), testLogrotateRsyslog)
+	writeTestFile(t, filepath.Join(confDir, "rsyslog.dpkg-old"), "/var/log/old {\n}\n")
+
+	rules := loadLogrotateRules(confFile, confDir)
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(rules))
+	}
+	for _, rule := range rules {
+		if rule.matches("/var/log/old") {
+			t.Error("Expected rules from dpkg leftovers to be ignored")
+		}
+	}
+}
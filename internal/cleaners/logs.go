@@ -0,0 +1,183 @@
+package cleaners
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const varLogDir = "/var/log"
+
+var compressedLogSuffixes = []string{".gz", ".xz", ".bz2", ".zst", ".lz4", ".Z"}
+
+type rotatedLog struct {
+	path       string
+	base       string
+	rule       int
+	modTime    time.Time
+	compressed bool
+}
+
+type rotatedLogGroup struct {
+	base string
+	rule int
+}
+
+// oldLogRule covers the single .old generation Xorg and others keep without logrotate.
+var oldLogRule = logrotateRule{rotate: 1}
+
+const oldLogRuleIndex = -1
+
+type logGenerations struct {
+	rules    []logrotateRule
+	patterns []*regexp.Regexp
+}
+
+func newLogGenerations(rules []logrotateRule) *logGenerations {
+	g := &logGenerations{rules: rules}
+	for _, rule := range rules {
+		g.patterns = append(g.patterns, rule.generationPattern())
+	}
+	return g
+}
+
+func (g *logGenerations) rule(i int) logrotateRule {
+	if i == oldLogRuleIndex {
+		return oldLogRule
+	}
+	return g.rules[i]
+}
+
+// parse only recognises logs some rule covers, leaving alone data that merely looks rotated such as MySQL binlogs.
+func (g *logGenerations) parse(path string) (rotatedLog, bool) {
+	name := path
+	compressed := false
+	for _, suffix := range compressedLogSuffixes {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok {
+			name = trimmed
+			compressed = true
+			break
+		}
+	}
+
+	for i, rule := range g.rules {
+		m := g.patterns[i].FindStringSubmatch(filepath.Base(name))
+		if m == nil {
+			continue
+		}
+		base := filepath.Join(filepath.Dir(name), m[1])
+		if rule.matches(base) {
+			return rotatedLog{path: path, base: base, rule: i, compressed: compressed}, true
+		}
+	}
+	if base, ok := strings.CutSuffix(name, ".old"); ok && filepath.Base(name) != ".old" {
+		return rotatedLog{path: path, base: base, rule: oldLogRuleIndex, compressed: compressed}, true
+	}
+	return rotatedLog{}, false
+}
+
+func findRotatedLogs(dir string, generations *logGenerations) map[rotatedLogGroup][]rotatedLog {
+	groups := make(map[rotatedLogGroup][]rotatedLog)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			// systemd journals are handled by the journal cleaner.
+			if path != dir && d.Name() == "journal" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		log, ok := generations.parse(path)
+		if !ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		log.modTime = info.ModTime()
+		key := rotatedLogGroup{log.base, log.rule}
+		groups[key] = append(groups[key], log)
+		return nil
+	})
+
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i].modTime.After(group[j].modTime) })
+	}
+	return groups
+}
+
+func cleanRotatedLogs(dir string, rules []logrotateRule, limit int, action, suffix string, open map[string][]int) {
+	removed, compressed := 0, 0
+	var saved int64
+
+	generations := newLogGenerations(rules)
+	groups := findRotatedLogs(dir, generations)
+	keys := make([]rotatedLogGroup, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].base != keys[j].base {
+			return keys[i].base < keys[j].base
+		}
+		return keys[i].rule < keys[j].rule
+	})
+
+	compress := func(path, suffix string) {
+		n, err := compressFile(path, suffix)
+		if err != nil {
+			fmt.Printf("Warning: Failed to compress %s: %v\n", path, err)
+			return
+		}
+		compressed++
+		saved += n
+	}
+
+	for _, key := range keys {
+		rule := generations.rule(key.rule)
+		keep := rule.retention(limit)
+		for i, log := range groups[key] {
+			if pids, ok := open[log.path]; ok {
+				fmt.Printf("Skipping %s (held open by pid %d)\n", log.path, pids[0])
+				continue
+			}
+
+			if i >= keep {
+				if action == logActionCompress {
+					if !log.compressed {
+						compress(log.path, suffix)
+					}
+					continue
+				}
+				size := fileSize(log.path)
+				if err := os.Remove(log.path); err != nil {
+					fmt.Printf("Warning: Failed to remove %s: %v\n", log.path, err)
+					continue
+				}
+				removed++
+				saved += size
+				continue
+			}
+
+			if rule.compress && !log.compressed && !(rule.delaycompress && i == 0) {
+				compress(log.path, ".gz")
+			}
+		}
+	}
+
+	recordReclaimed(saved)
+	fmt.Printf("Removed %d and compressed %d rotated log file(s), %s saved\n", removed, compressed, utils.FormatBytes(uint64(saved)))
+}
@@ -0,0 +1,161 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogGenerations(t *testing.T) {
+	rules := []logrotateRule{
+		{patterns: []string{"/var/log/syslog", "/var/log/auth.log"}},
+		{patterns: []string{"/var/log/messages"}, dateext: true},
+		{patterns: []string{"/var/log/app/*.log"}, dateext: true, dateformat: "-%Y-%m-%d-%s"},
+		{patterns: []string{"/var/log/mysql/*"}},
+	}
+	generations := newLogGenerations(rules)
+
+	tests := []struct {
+		path       string
+		base       string
+		compressed bool
+		ok         bool
+	}{
+		{"/var/log/syslog.1", "/var/log/syslog", false, true},
+		{"/var/log/syslog.2.gz", "/var/log/syslog", true, true},
+		{"/var/log/auth.log.1", "/var/log/auth.log", false, true},
+		{"/var/log/messages-20240107", "/var/log/messages", false, true},
+		{"/var/log/messages-20240107.xz", "/var/log/messages", true, true},
+		{"/var/log/messages.3", "/var/log/messages", false, true},
+		{"/var/log/app/server.log-2024-01-07-1704585600.gz", "/var/log/app/server.log", true, true},
+		{"/var/log/syslog-20240107", "", false, false},
+		{"/var/log/app/server.log-20240107", "", false, false},
+		{"/var/log/mysql/mysql-bin.000123", "", false, false},
+		{"/var/log/kern.log.1", "", false, false},
+		{"/var/log/Xorg.0.log.old", "/var/log/Xorg.0.log", false, true},
+		{"/var/log/Xorg.1.log.old.gz", "/var/log/Xorg.1.log", true, true},
+		{"/var/log/syslog", "", false, false},
+		{"/var/log/apt/eipp.log.xz", "", false, false},
+	}
+
+	for _, tt := range tests {
+		log, ok := generations.parse(tt.path)
+		if ok != tt.ok || log.base != tt.base || log.compressed != tt.compressed {
+			t.Errorf("parse(%q) = (%+v, %v); want base %q, compressed %v, ok %v", tt.path, log, ok, tt.base, tt.compressed, tt.ok)
+		}
+	}
+}
+
+func TestCleanRotatedLogs(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"syslog", "syslog.1", "syslog.2", "syslog.3.gz", "syslog.4.gz",
+		"app.log", "app.log.1", "app.log.2", "app.log.3",
+		"held.log.1", "held.log.2", "held.log.3",
+		"journal/system.journal",
+		"stale.log", "stale.log.1", "stale.log.2", "stale.log.3",
+		"mysql/mysql-bin.000001", "mysql/mysql-bin.000002", "mysql/mysql-bin.000003",
+	)
+
+	// Give each generation a distinct age so that .1 is the newest.
+	now := time.Now()
+	for i, name := range []string{"syslog.1", "syslog.2", "syslog.3.gz", "syslog.4.gz", "app.log.1", "app.log.2", "app.log.3", "held.log.1", "held.log.2", "held.log.3"} {
+		generation := name[len(name)-1] - '0'
+		if name[len(name)-3:] == ".gz" {
+			generation = name[len(name)-4] - '0'
+		}
+		mtime := now.Add(-time.Duration(generation) * 24 * time.Hour).Add(-time.Duration(i) * time.Second)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := []logrotateRule{
+		{patterns: []string{filepath.Join(dir, "syslog")}, rotate: 4, compress: true, delaycompress: true},
+		{patterns: []string{filepath.Join(dir, "app.log"), filepath.Join(dir, "held.log"), filepath.Join(dir, "mysql/*")}, rotate: 4},
+	}
+	open := map[string][]int{filepath.Join(dir, "held.log.3"): {42}}
+
+	cleanRotatedLogs(dir, rules, 2, logActionDelete, ".gz", open)
+
+	assertFilesExist(t, dir, "syslog", "syslog.1", "syslog.2.gz", "app.log", "app.log.1", "app.log.2", "held.log.1", "held.log.2", "held.log.3", "journal/system.journal",
+		"stale.log.1", "stale.log.2", "stale.log.3", "mysql/mysql-bin.000001", "mysql/mysql-bin.000002", "mysql/mysql-bin.000003")
+	assertFilesRemoved(t, dir, "syslog.2", "syslog.3.gz", "syslog.4.gz", "app.log.3")
+}
+
+func TestCleanRotatedLogsCompressAction(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "kern.log", "kern.log.1", "kern.log.2", "kern.log.3", "kern.log.4.gz")
+	now := time.Now()
+	for i, name := range []string{"kern.log.1", "kern.log.2", "kern.log.3", "kern.log.4.gz"} {
+		mtime := now.Add(-time.Duration(i+1) * 24 * time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := []logrotateRule{{patterns: []string{filepath.Join(dir, "kern.log")}, rotate: 4}}
+	cleanRotatedLogs(dir, rules, 1, logActionCompress, ".gz", nil)
+
+	assertFilesExist(t, dir, "kern.log", "kern.log.1", "kern.log.2.gz", "kern.log.3.gz", "kern.log.4.gz")
+	assertFilesRemoved(t, dir, "kern.log.2", "kern.log.3")
+}
+
+func TestCleanRotatedLogsOverlappingRules(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"server.log", "server.log.1", "server.log.2",
+		"server.log-20240101", "server.log-20240102", "server.log-20240103",
+	)
+	now := time.Now()
+	for i, name := range []string{"server.log-20240103", "server.log-20240102", "server.log-20240101", "server.log.1", "server.log.2"} {
+		mtime := now.Add(-time.Duration(i+1) * 24 * time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both rules cover server.log. The dated generations were rotated under
+	// the second rule and keep its count, not the first rule's.
+	rules := []logrotateRule{
+		{patterns: []string{filepath.Join(dir, "*.log")}, rotate: 1},
+		{patterns: []string{filepath.Join(dir, "server.log")}, rotate: 2, dateext: true},
+	}
+	cleanRotatedLogs(dir, rules, 0, logActionDelete, ".gz", nil)
+
+	assertFilesExist(t, dir, "server.log", "server.log.1", "server.log-20240103", "server.log-20240102")
+	assertFilesRemoved(t, dir, "server.log.2", "server.log-20240101")
+}
+
+func TestCleanRotatedLogsRetention(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"auth.log", "auth.log.1", "auth.log.2", "auth.log.3",
+		"kern.log", "kern.log.1", "kern.log.2", "kern.log.3", "kern.log.4",
+		"unset.log", "unset.log.1",
+		"Xorg.0.log", "Xorg.0.log.old",
+	)
+	now := time.Now()
+	for _, name := range []string{"auth.log.1", "auth.log.2", "auth.log.3", "kern.log.1", "kern.log.2", "kern.log.3", "kern.log.4", "unset.log.1"} {
+		mtime := now.Add(-time.Duration(name[len(name)-1]-'0') * 24 * time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules := []logrotateRule{
+		{patterns: []string{filepath.Join(dir, "auth.log")}, rotate: 1},
+		{patterns: []string{filepath.Join(dir, "kern.log")}, rotate: 3},
+		{patterns: []string{filepath.Join(dir, "unset.log")}, rotate: -1},
+	}
+
+	// Without a limit, each log keeps the rotate count of its rule.
+	cleanRotatedLogs(dir, rules, 0, logActionDelete, ".gz", nil)
+	assertFilesExist(t, dir, "auth.log.1", "kern.log.1", "kern.log.2", "kern.log.3", "Xorg.0.log.old")
+	assertFilesRemoved(t, dir, "auth.log.2", "auth.log.3", "kern.log.4", "unset.log.1")
+
+	// A limit below the rotate count tightens it.
+	cleanRotatedLogs(dir, rules, 2, logActionDelete, ".gz", nil)
+	assertFilesExist(t, dir, "auth.log.1", "kern.log.1", "kern.log.2")
+	assertFilesRemoved(t, dir, "kern.log.3")
+}
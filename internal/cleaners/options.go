@@ -0,0 +1,75 @@
+package cleaners
+
+// Options holds the user-tunable settings of the cleaners.
+type Options struct {
+	// RemovePackages lists package names, globs and /regular expressions/
+	// removed by the packages cleaner.
+	RemovePackages []string
+	// KeepCacheVersions is the number of versions of each installed package
+	// kept in the APT, DNF/YUM and pacman caches.
+	KeepCacheVersions int
+	// LogKeepRotations caps the number of rotated generations of each log the
+	// logs cleaner keeps, which is otherwise the rotate count of its logrotate
+	// rule. 0 disables the cap.
+	LogKeepRotations int
+	// LogAction is what the logs and user_logs cleaners do with eligible
+	// files: "delete" or "compress".
+	LogAction string
+	// KeepCoredumps is the number of core dumps and crash reports of each
+	// executable kept by the crash cleaner.
+	KeepCoredumps int
+	// CoredumpMaxAgeDays is the age in days past which the crash cleaner
+	// removes dumps even when they are among the newest. 0 disables the limit.
+	CoredumpMaxAgeDays int
+	// JournalVacuumSize, JournalVacuumTime and JournalVacuumFiles are the
+	// limits the journal cleaner vacuums to, in journalctl syntax. When none
+	// is set, the limits in journald.conf are used.
+	JournalVacuumSize  string
+	JournalVacuumTime  string
+	JournalVacuumFiles int
+	// TrashMaxAgeDays is the number of days items stay in the trash before
+	// the trash cleaner empties them. 0 empties every item.
+	TrashMaxAgeDays int
+	// CacheMaxAgeDays is the number of days a file in a user cache must have
+	// gone unused before the cache cleaner removes it. 0 removes every file.
+	CacheMaxAgeDays int
+	// CacheInclude and CacheExclude select the applications, by name of
+	// their directory in ~/.cache, that the cache cleaner cleans. Entries are
+	// names, globs or /regular expressions/. An empty CacheInclude means all.
+	CacheInclude []string
+	CacheExclude []string
+	// RecentFilesMaxAgeDays and RecentFilesMaxEntries bound the entries the
+	// privacy_recent_files cleaner keeps in recently-used.xbel. 0 disables
+	// a limit.
+	RecentFilesMaxAgeDays int
+	RecentFilesMaxEntries int
+	// JetBrainsRemoveConfigs makes the jetbrains cleaner remove the configs
+	// of old IDE versions along with their caches, indexes and logs.
+	JetBrainsRemoveConfigs bool
+	// ProjectMaxAgeDays is the number of days the source of a project must
+	// have gone unchanged before the project_artifacts cleaner removes its
+	// build artifacts.
+	ProjectMaxAgeDays int
+}
+
+var options = DefaultOptions()
+
+// DefaultOptions returns the settings used when none are given on the command line
+func DefaultOptions() Options {
+	return Options{
+		KeepCacheVersions:     2,
+		LogAction:             logActionDelete,
+		KeepCoredumps:         1,
+		CoredumpMaxAgeDays:    30,
+		TrashMaxAgeDays:       30,
+		CacheMaxAgeDays:       30,
+		RecentFilesMaxAgeDays: 30,
+		RecentFilesMaxEntries: 500,
+		ProjectMaxAgeDays:     90,
+	}
+}
+
+// SetOptions replaces the settings used by the cleaners
+func SetOptions(o Options) {
+	options = o
+}
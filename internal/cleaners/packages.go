@@ -0,0 +1,171 @@
+package cleaners
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+type nameMatcher func(name string) bool
+
+func init() {
+	registerCleanup("packages", Cleaner{CleanupFunc: removePackages, RequiresConfirmation: false})
+}
+
+func removePackages() error {
+	return removePackagesWith(options.RemovePackages, dpkgStatusFile, utils.CommandExists, utils.Confirm)
+}
+
+func removePackagesWith(patterns []string, statusFile string, commandExists utils.CommandExistsFunc, confirm utils.ConfirmFunc) error {
+	if len(patterns) == 0 {
+		fmt.Println("Package removal: Skipped (no packages configured, see -remove-packages)")
+		return nil
+	}
+	if !commandExists("apt-get") {
+		fmt.Println("Package removal: Skipped (apt-get not installed)")
+		return nil
+	}
+
+	matchers, err := compileNamePatterns(patterns)
+	if err != nil {
+		return err
+	}
+
+	packages, err := readDpkgStatus(statusFile)
+	if err != nil {
+		return fmt.Errorf("failed to read dpkg status database: %v", err)
+	}
+
+	byName := make(map[string]dpkgPackage)
+	var targets []string
+	for _, pkg := range packages {
+		if !pkg.installed() {
+			continue
+		}
+		byName[pkg.qualifiedName()] = pkg
+		if !matchesAny(matchers, pkg.name) {
+			continue
+		}
+		if isProtectedPackage(pkg) {
+			fmt.Printf("Refusing to remove %s (essential or priority required)\n", pkg.name)
+			continue
+		}
+		targets = append(targets, pkg.qualifiedName())
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("No installed packages match the configured removal list")
+		return nil
+	}
+
+	output, err := utils.Runner.RunWithOutput(fmt.Sprintf("apt-get -s purge %s", strings.Join(targets, " ")))
+	if err != nil {
+		return fmt.Errorf("failed to simulate package removal: %v", err)
+	}
+
+	requested := make(map[string]bool)
+	for _, t := range targets {
+		requested[t] = true
+	}
+
+	var protected []string
+	fmt.Println("The following packages would be removed:")
+	for _, name := range parseAptSimulation(output) {
+		note := " (dependency)"
+		isProtected := false
+		for _, pkg := range resolvePackageName(byName, name) {
+			if requested[pkg.qualifiedName()] {
+				note = ""
+			}
+			isProtected = isProtected || isProtectedPackage(pkg)
+		}
+		fmt.Printf("  %s%s\n", name, note)
+		if isProtected {
+			protected = append(protected, name)
+		}
+	}
+
+	if len(protected) > 0 {
+		return fmt.Errorf("refusing to remove essential or required package(s): %s", strings.Join(protected, ", "))
+	}
+
+	if !confirm("Remove the packages listed above") {
+		fmt.Println("Skipping package removal")
+		return nil
+	}
+	return utils.Runner.RunWithIndicator(fmt.Sprintf("apt-get purge -y %s", strings.Join(targets, " ")), "Removing configured packages...")
+}
+
+// resolvePackageName resolves a bare name to every architecture, as apt-get omits the native one.
+func resolvePackageName(byName map[string]dpkgPackage, name string) []dpkgPackage {
+	if pkg, ok := byName[name]; ok {
+		return []dpkgPackage{pkg}
+	}
+	var matches []dpkgPackage
+	if !strings.Contains(name, ":") {
+		for _, pkg := range byName {
+			if pkg.name == name {
+				matches = append(matches, pkg)
+			}
+		}
+	}
+	return matches
+}
+
+// compileNamePatterns accepts exact names, globs and /regular expressions/.
+func compileNamePatterns(patterns []string) ([]nameMatcher, error) {
+	var matchers []nameMatcher
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		switch {
+		case p == "":
+			continue
+		case len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/"):
+			re, err := regexp.Compile("^(?:" + p[1:len(p)-1] + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %v", p, err)
+			}
+			matchers = append(matchers, re.MatchString)
+		case strings.ContainsAny(p, "*?["):
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %v", p, err)
+			}
+			glob := p
+			matchers = append(matchers, func(name string) bool {
+				matched, _ := path.Match(glob, name)
+				return matched
+			})
+		default:
+			exact := p
+			matchers = append(matchers, func(name string) bool { return name == exact })
+		}
+	}
+	return matchers, nil
+}
+
+func matchesAny(matchers []nameMatcher, name string) bool {
+	for _, m := range matchers {
+		if m(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isProtectedPackage(pkg dpkgPackage) bool {
+	return pkg.essential || pkg.priority == "required"
+}
+
+func parseAptSimulation(output string) []string {
+	var removed []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (fields[0] == "Purg" || fields[0] == "Remv") {
+			removed = append(removed, fields[1])
+		}
+	}
+	return removed
+}
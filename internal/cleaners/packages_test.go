@@ -0,0 +1,112 @@
+package cleaners
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompilePackagePatterns(t *testing.T) {
+	matchers, err := compileNamePatterns([]string{"nano", "vim-*", "/^libfoo[0-9]+$/"})
+	if err != nil {
+		t.Fatalf("compileNamePatterns() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"nano", true},
+		{"nano-tiny", false},
+		{"vim-tiny", true},
+		{"vim", false},
+		{"libfoo12", true},
+		{"libfoo", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesAny(matchers, tt.name); got != tt.expected {
+			t.Errorf("matchesAny(%q) = %v; want %v", tt.name, got, tt.expected)
+		}
+	}
+
+	if _, err := compileNamePatterns([]string{"/[/"}); err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+}
+
+func TestParseAptSimulation(t *testing.T) {
+	output := `Reading package lists...
+The following packages will be REMOVED:
+  nano* vim-tiny*
+Purg nano [7.2-1]
+Remv vim-common [2:9.0.1378-2] [vim-tiny:amd64 ]
+Purg vim-tiny [2:9.0.1378-2]
+`
+	removed := parseAptSimulation(output)
+	expected := []string{"nano", "vim-common", "vim-tiny"}
+	if strings.Join(removed, ",") != strings.Join(expected, ",") {
+		t.Errorf("parseAptSimulation() = %v; want %v", removed, expected)
+	}
+}
+
+// Multi-arch packages where only the foreign architecture is required.
+const testMultiarchStatus = `
+Package: libfoo1
+Status: install ok installed
+Priority: optional
+Architecture: amd64
+Version: 1.0-1
+
+Package: libfoo1
+Status: install ok installed
+Priority: required
+Architecture: i386
+Version: 1.0-1
+
+Package: foo-tools
+Status: install ok installed
+Priority: optional
+Architecture: amd64
+Version: 1.0-1
+`
+
+func TestRemovePackagesWith(t *testing.T) {
+	statusFile := filepath.Join(t.TempDir(), "status")
+	writeTestFile(t, statusFile, testDpkgStatus+testMultiarchStatus)
+	commandExists := func(string) bool { return true }
+
+	tests := []struct {
+		name             string
+		patterns         []string
+		simulation       string
+		confirm          bool
+		expectErr        bool
+		expectedCommands []string
+	}{
+		{"NoPatterns", nil, "", true, false, nil},
+		{"Confirmed", []string{"nano"}, "Purg nano [7.2-1]\n", true, false, []string{"apt-get -s purge nano:amd64", "apt-get purge -y nano:amd64"}},
+		{"Declined", []string{"nano"}, "Purg nano [7.2-1]\n", false, false, []string{"apt-get -s purge nano:amd64"}},
+		{"EssentialRequested", []string{"bash"}, "", true, false, nil},
+		{"EssentialDependency", []string{"nano"}, "Purg nano [7.2-1]\nRemv bash [5.2.15-2+b2]\n", true, true, []string{"apt-get -s purge nano:amd64"}},
+		{"RequiredForeignArch", []string{"foo-tools"}, "Purg foo-tools [1.0-1]\nRemv libfoo1 [1.0-1]\n", true, true, []string{"apt-get -s purge foo-tools:amd64"}},
+		{"OptionalNativeArch", []string{"foo-tools"}, "Purg foo-tools [1.0-1]\nRemv libfoo1:amd64 [1.0-1]\n", true, false,
+			[]string{"apt-get -s purge foo-tools:amd64", "apt-get purge -y foo-tools:amd64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := setupTest()
+			mock.RunWithOutputFunc = func(command string) (string, error) { return tt.simulation, nil }
+
+			err := removePackagesWith(tt.patterns, statusFile, commandExists, func(string) bool { return tt.confirm })
+			if (err != nil) != tt.expectErr {
+				t.Errorf("removePackagesWith() error = %v, expectErr %v", err, tt.expectErr)
+			}
+
+			if strings.Join(mock.Commands, "|") != strings.Join(tt.expectedCommands, "|") {
+				t.Errorf("Expected commands %v, got %v", tt.expectedCommands, mock.Commands)
+			}
+		})
+	}
+}
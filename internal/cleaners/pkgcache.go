@@ -0,0 +1,222 @@
+package cleaners
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const (
+	aptArchivesDir = "/var/cache/apt/archives"
+	pacmanCacheDir = "/var/cache/pacman/pkg"
+)
+
+var rpmCacheDirs = []string{"/var/cache/dnf", "/var/cache/yum"}
+
+type cachedPackage struct {
+	path    string
+	key     string
+	version string
+	size    int64
+}
+
+// parseDebFilename parses name_version_arch.deb, with the epoch colon escaped as %3a.
+func parseDebFilename(name string) (key, version string, ok bool) {
+	base, found := strings.CutSuffix(name, ".deb")
+	if !found {
+		return "", "", false
+	}
+	parts := strings.Split(base, "_")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	version, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", false
+	}
+	return parts[0] + ":" + parts[2], version, true
+}
+
+func parseRpmFilename(name string) (key, version string, ok bool) {
+	base, found := strings.CutSuffix(name, ".rpm")
+	if !found {
+		return "", "", false
+	}
+	dot := strings.LastIndexByte(base, '.')
+	if dot < 0 {
+		return "", "", false
+	}
+	arch := base[dot+1:]
+	nvr := base[:dot]
+	relDash := strings.LastIndexByte(nvr, '-')
+	if relDash < 0 {
+		return "", "", false
+	}
+	verDash := strings.LastIndexByte(nvr[:relDash], '-')
+	if verDash < 0 {
+		return "", "", false
+	}
+	return nvr[:verDash] + "." + arch, nvr[verDash+1:], true
+}
+
+func parsePacmanFilename(name string) (key, version string, ok bool) {
+	i := strings.Index(name, ".pkg.tar")
+	if i < 0 || strings.HasSuffix(name, ".sig") || strings.HasSuffix(name, ".part") {
+		return "", "", false
+	}
+	parts := strings.Split(name[:i], "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	n := len(parts)
+	return strings.Join(parts[:n-3], "-"), parts[n-3] + "-" + parts[n-2], true
+}
+
+func scanPackageCache(dir string, recursive bool, parse func(string) (string, string, bool)) ([]cachedPackage, error) {
+	var packages []cachedPackage
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		key, version, ok := parse(d.Name())
+		if !ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		packages = append(packages, cachedPackage{path: path, key: key, version: version, size: info.Size()})
+		return nil
+	})
+	return packages, err
+}
+
+func selectStalePackages(packages []cachedPackage, installed map[string]string, keep int) []cachedPackage {
+	groups := make(map[string][]cachedPackage)
+	for _, p := range packages {
+		groups[p.key] = append(groups[p.key], p)
+	}
+
+	var stale []cachedPackage
+	for key, group := range groups {
+		installedVersion, isInstalled := installed[key]
+		if !isInstalled {
+			stale = append(stale, group...)
+			continue
+		}
+
+		sort.Slice(group, func(i, j int) bool { return compareVersions(group[i].version, group[j].version) > 0 })
+		kept := 0
+		for _, p := range group {
+			if kept < keep || (keep > 0 && p.version == installedVersion) {
+				kept++
+				continue
+			}
+			stale = append(stale, p)
+		}
+	}
+
+	sort.Slice(stale, func(i, j int) bool { return stale[i].path < stale[j].path })
+	return stale
+}
+
+func removeCachedPackages(label string, stale []cachedPackage) {
+	var freed int64
+	removed := 0
+	for _, p := range stale {
+		if err := os.Remove(p.path); err != nil {
+			fmt.Printf("Warning: Failed to remove %s: %v\n", p.path, err)
+			continue
+		}
+		// pacman keeps detached signatures next to the package.
+		os.Remove(p.path + ".sig")
+		freed += p.size
+		removed++
+	}
+	fmt.Printf("%s: removed %d cached package(s), %s\n", label, removed, utils.FormatBytes(uint64(freed)))
+}
+
+func pruneAptCache(dir, statusFile string, keep int) error {
+	packages, err := readDpkgStatus(statusFile)
+	if err != nil {
+		return fmt.Errorf("failed to read dpkg status database: %v", err)
+	}
+	installed := make(map[string]string)
+	for _, pkg := range packages {
+		if pkg.installed() {
+			installed[pkg.name+":"+pkg.arch] = pkg.version
+		}
+	}
+
+	cached, err := scanPackageCache(dir, false, parseDebFilename)
+	if err != nil {
+		return fmt.Errorf("failed to scan APT cache: %v", err)
+	}
+	removeCachedPackages("APT cache", selectStalePackages(cached, installed, keep))
+	return nil
+}
+
+func pruneRpmCache(dirs []string, keep int) error {
+	output, err := utils.Runner.RunWithOutput("rpm -qa --qf '%{NAME} %{VERSION}-%{RELEASE} %{ARCH}\\n'")
+	if err != nil {
+		return fmt.Errorf("failed to list installed RPM packages: %v", err)
+	}
+	installed := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 {
+			installed[fields[0]+"."+fields[2]] = fields[1]
+		}
+	}
+
+	var cached []cachedPackage
+	for _, dir := range dirs {
+		found, err := scanPackageCache(dir, true, parseRpmFilename)
+		if err != nil {
+			continue
+		}
+		cached = append(cached, found...)
+	}
+	removeCachedPackages("RPM cache", selectStalePackages(cached, installed, keep))
+	return nil
+}
+
+func prunePacmanCache(dir string, keep int) error {
+	output, err := utils.Runner.RunWithOutput("pacman -Q")
+	if err != nil {
+		return fmt.Errorf("failed to list installed pacman packages: %v", err)
+	}
+	installed := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			installed[fields[0]] = fields[1]
+		}
+	}
+
+	cached, err := scanPackageCache(dir, false, parsePacmanFilename)
+	if err != nil {
+		return fmt.Errorf("failed to scan pacman cache: %v", err)
+	}
+	removeCachedPackages("pacman cache", selectStalePackages(cached, installed, keep))
+	return nil
+}
@@ -0,0 +1,112 @@
+package cleaners
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParsePackageFilenames(t *testing.T) {
+	tests := []struct {
+		parse   func(string) (string, string, bool)
+		name    string
+		key     string
+		version string
+		ok      bool
+	}{
+		{parseDebFilename, "libc6_2.36-9%2bdeb12u4_amd64.deb", "libc6:amd64", "2.36-9+deb12u4", true},
+		{parseDebFilename, "vim_2%3a9.0.1378-2_amd64.deb", "vim:amd64", "2:9.0.1378-2", true},
+		{parseDebFilename, "lock", "", "", false},
+		{parseRpmFilename, "kernel-core-6.5.6-300.fc39.x86_64.rpm", "kernel-core.x86_64", "6.5.6-300.fc39", true},
+		{parseRpmFilename, "python3-libs-3.12.0-1.fc39.noarch.rpm", "python3-libs.noarch", "3.12.0-1.fc39", true},
+		{parseRpmFilename, "repomd.xml", "", "", false},
+		{parsePacmanFilename, "linux-firmware-20231030.1a2b3c-1-any.pkg.tar.zst", "linux-firmware", "20231030.1a2b3c-1", true},
+		{parsePacmanFilename, "lib32-glibc-2.38-7-x86_64.pkg.tar.zst", "lib32-glibc", "2.38-7", true},
+		{parsePacmanFilename, "lib32-glibc-2.38-7-x86_64.pkg.tar.zst.sig", "", "", false},
+	}
+
+	for _, tt := range tests {
+		key, version, ok := tt.parse(tt.name)
+		if key != tt.key || version != tt.version || ok != tt.ok {
+			t.Errorf("parse(%q) = (%q, %q, %v); want (%q, %q, %v)", tt.name, key, version, ok, tt.key, tt.version, tt.ok)
+		}
+	}
+}
+
+func TestSelectStalePackages(t *testing.T) {
+	packages := []cachedPackage{
+		{path: "a-1", key: "a", version: "1.0-1"},
+		{path: "a-2", key: "a", version: "1.1-1"},
+		{path: "a-3", key: "a", version: "1.2-1"},
+		{path: "a-4", key: "a", version: "1.10-1"},
+		{path: "b-1", key: "b", version: "2.0"},
+		{path: "c-1", key: "c", version: "0.1"},
+		{path: "c-2", key: "c", version: "0.2"},
+	}
+	installed := map[string]string{"a": "1.0-1", "c": "0.2"}
+
+	tests := []struct {
+		keep     int
+		expected []string
+	}{
+		{2, []string{"a-2", "b-1"}},
+		{1, []string{"a-2", "a-3", "b-1", "c-1"}},
+		{0, []string{"a-1", "a-2", "a-3", "a-4", "b-1", "c-1", "c-2"}},
+	}
+
+	for _, tt := range tests {
+		var paths []string
+		for _, p := range selectStalePackages(packages, installed, tt.keep) {
+			paths = append(paths, p.path)
+		}
+		sort.Strings(paths)
+		if strings.Join(paths, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("selectStalePackages(keep=%d) = %v; want %v", tt.keep, paths, tt.expected)
+		}
+	}
+}
+
+func TestPrunePacmanCache(t *testing.T) {
+	mock := setupTest()
+	mock.RunWithOutputFunc = func(command string) (string, error) {
+		return "bash 5.2.015-5\nzstd 1.5.5-1\n", nil
+	}
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"bash-5.2.015-3-x86_64.pkg.tar.zst", "bash-5.2.015-3-x86_64.pkg.tar.zst.sig",
+		"bash-5.2.015-4-x86_64.pkg.tar.zst",
+		"bash-5.2.015-5-x86_64.pkg.tar.zst",
+		"gone-1.0-1-any.pkg.tar.xz",
+	)
+
+	if err := prunePacmanCache(dir, 2); err != nil {
+		t.Fatalf("prunePacmanCache() error = %v", err)
+	}
+
+	assertFilesExist(t, dir, "bash-5.2.015-4-x86_64.pkg.tar.zst", "bash-5.2.015-5-x86_64.pkg.tar.zst")
+	assertFilesRemoved(t, dir, "bash-5.2.015-3-x86_64.pkg.tar.zst", "bash-5.2.015-3-x86_64.pkg.tar.zst.sig", "gone-1.0-1-any.pkg.tar.xz")
+}
+
+func TestPruneRpmCache(t *testing.T) {
+	mock := setupTest()
+	mock.RunWithOutputFunc = func(command string) (string, error) {
+		return "kernel-core 6.5.7-300.fc39 x86_64\n", nil
+	}
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"fedora/packages/kernel-core-6.5.5-300.fc39.x86_64.rpm",
+		"updates/packages/kernel-core-6.5.6-300.fc39.x86_64.rpm",
+		"updates/packages/kernel-core-6.5.7-300.fc39.x86_64.rpm",
+		"updates/packages/kernel-core-6.5.7-300.fc39.i686.rpm",
+	)
+
+	if err := pruneRpmCache([]string{dir, filepath.Join(dir, "missing")}, 2); err != nil {
+		t.Fatalf("pruneRpmCache() error = %v", err)
+	}
+
+	assertFilesExist(t, dir, "updates/packages/kernel-core-6.5.6-300.fc39.x86_64.rpm", "updates/packages/kernel-core-6.5.7-300.fc39.x86_64.rpm")
+	assertFilesRemoved(t, dir, "fedora/packages/kernel-core-6.5.5-300.fc39.x86_64.rpm", "updates/packages/kernel-core-6.5.7-300.fc39.i686.rpm")
+}
@@ -0,0 +1,159 @@
+package cleaners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+func init() {
+	registerPrivacyCleanup("privacy_browser_history", func(home string) []privacyItem { return browserHistoryItems(home, procDir) })
+	registerPrivacyCleanup("privacy_cookies", func(home string) []privacyItem { return browserCookieItems(home, procDir) })
+	registerCleanup("privacy_recent_files", Cleaner{CleanupFunc: trimRecentFiles, Category: CategoryPrivacy})
+	registerPrivacyCleanup("privacy_shell_history", shellHistoryItems)
+	registerPrivacyCleanup("privacy_viminfo", homeFileItems(".viminfo"))
+	registerPrivacyCleanup("privacy_lesshst", homeFileItems(".lesshst"))
+}
+
+func registerPrivacyCleanup(name string, find func(home string) []privacyItem) {
+	registerCleanup(name, Cleaner{
+		CleanupFunc: func() error {
+			var items []privacyItem
+			for _, home := range userHomes(passwdFile) {
+				items = append(items, find(home.dir)...)
+			}
+			return erasePrivacyItems(name, items, utils.CommandExists, utils.Confirm, newDeleter())
+		},
+		Category: CategoryPrivacy,
+	})
+}
+
+// privacyItem is removed with its SQLite sidecars, or cleared with sql when it also holds data worth keeping.
+type privacyItem struct {
+	path string
+	sql  string
+}
+
+// History is cleared with SQL since places.sqlite also holds the bookmarks.
+const firefoxHistorySQL = "DELETE FROM moz_historyvisits; DELETE FROM moz_inputhistory; " +
+	"DELETE FROM moz_places WHERE foreign_count = 0; UPDATE moz_places SET visit_count = 0, last_visit_date = NULL;"
+
+var chromiumHistoryFiles = []string{"History", "Visited Links", "Top Sites", "Shortcuts"}
+
+var chromiumCookieFiles = []string{"Cookies", "Network/Cookies"}
+
+var shellHistoryFiles = []string{".bash_history", ".zsh_history", ".histfile", ".local/share/fish/fish_history"}
+
+func existingItems(paths ...string) []privacyItem {
+	var items []privacyItem
+	for _, path := range paths {
+		if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+			items = append(items, privacyItem{path: path})
+		}
+	}
+	return items
+}
+
+func idleBrowserProfiles(home, proc string) []browserProfile {
+	var idle []browserProfile
+	for _, profile := range findBrowserProfiles(home, proc) {
+		if profile.pid != 0 {
+			fmt.Printf("Skipping %s profile %s in %s: browser is running (pid %d)\n", profile.browser, profile.name, home, profile.pid)
+			continue
+		}
+		idle = append(idle, profile)
+	}
+	return idle
+}
+
+func browserHistoryItems(home, proc string) []privacyItem {
+	var items []privacyItem
+	for _, profile := range idleBrowserProfiles(home, proc) {
+		if !profile.firefox {
+			for _, name := range chromiumHistoryFiles {
+				items = append(items, existingItems(filepath.Join(profile.dir, name))...)
+			}
+			continue
+		}
+		for _, item := range existingItems(filepath.Join(profile.dir, "places.sqlite")) {
+			item.sql = firefoxHistorySQL
+			items = append(items, item)
+		}
+		items = append(items, existingItems(filepath.Join(profile.dir, "formhistory.sqlite"))...)
+	}
+	return items
+}
+
+func browserCookieItems(home, proc string) []privacyItem {
+	var items []privacyItem
+	for _, profile := range idleBrowserProfiles(home, proc) {
+		if profile.firefox {
+			items = append(items, existingItems(filepath.Join(profile.dir, "cookies.sqlite"))...)
+			continue
+		}
+		for _, name := range chromiumCookieFiles {
+			items = append(items, existingItems(filepath.Join(profile.dir, name))...)
+		}
+	}
+	return items
+}
+
+func shellHistoryItems(home string) []privacyItem {
+	var items []privacyItem
+	for _, name := range shellHistoryFiles {
+		items = append(items, existingItems(filepath.Join(home, name))...)
+	}
+	return items
+}
+
+func homeFileItems(name string) func(home string) []privacyItem {
+	return func(home string) []privacyItem {
+		return existingItems(filepath.Join(home, name))
+	}
+}
+
+func erasePrivacyItems(name string, items []privacyItem, commandExists utils.CommandExistsFunc, confirm utils.ConfirmFunc, d *deleter) error {
+	if len(items) == 0 {
+		fmt.Println("Nothing to erase")
+		return nil
+	}
+
+	fmt.Println("The following items will be erased:")
+	for _, item := range items {
+		action := "remove"
+		if item.sql != "" {
+			action = "clear history"
+		}
+		fmt.Printf("  %-14s %10s  %s\n", action, utils.FormatBytes(uint64(fileSize(item.path))), item.path)
+	}
+	if !confirm(fmt.Sprintf("Erase the %d item(s) listed above", len(items))) {
+		fmt.Printf("Skipping %s\n", name)
+		return nil
+	}
+
+	for _, item := range items {
+		if item.sql != "" {
+			if !commandExists("sqlite3") {
+				fmt.Printf("Warning: sqlite3 is not installed, leaving %s untouched\n", item.path)
+				continue
+			}
+			command := fmt.Sprintf("sqlite3 %s %s", shellQuote(item.path), shellQuote(item.sql))
+			if err := utils.Runner.RunWithIndicator(command, "Clearing "+item.path+"..."); err != nil {
+				fmt.Printf("Warning: Failed to clear %s: %v\n", item.path, err)
+			}
+			restoreSidecarOwnership(item.path)
+			continue
+		}
+		if d.removeFile(item.path) {
+			for _, suffix := range sqliteSidecars {
+				if _, err := os.Lstat(item.path + suffix); err == nil {
+					d.removeFile(item.path + suffix)
+				}
+			}
+		}
+	}
+	d.report("privacy item(s)")
+	return nil
+}
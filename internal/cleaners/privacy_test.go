@@ -0,0 +1,127 @@
+package cleaners
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestErasePrivacyItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		confirmed bool
+		hasSqlite bool
+		commands  int
+		removed   bool
+	}{
+		{"Declined", false, true, 0, false},
+		{"Confirmed", true, true, 1, true},
+		{"NoSqlite", true, false, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := setupTest()
+			dir := t.TempDir()
+			writeTestFiles(t, dir, "History", "History-journal", "places.sqlite")
+			places := filepath.Join(dir, "places.sqlite")
+			items := []privacyItem{{path: filepath.Join(dir, "History")}, {path: places, sql: firefoxHistorySQL}}
+			commandExists := func(string) bool { return tt.hasSqlite }
+			confirm := func(string) bool { return tt.confirmed }
+
+			if err := erasePrivacyItems("privacy_browser_history", items, commandExists, confirm, &deleter{}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(mock.Commands) != tt.commands {
+				t.Fatalf("Expected %d commands, got %v", tt.commands, mock.Commands)
+			}
+			if tt.commands > 0 && !strings.HasPrefix(mock.Commands[0], "sqlite3 '"+places+"' 'DELETE FROM moz_historyvisits;") {
+				t.Errorf("Unexpected command: %s", mock.Commands[0])
+			}
+			if tt.removed {
+				assertFilesRemoved(t, dir, "History", "History-journal")
+			} else {
+				assertFilesExist(t, dir, "History", "History-journal")
+			}
+			assertFilesExist(t, dir, "places.sqlite")
+		})
+	}
+}
+
+func TestBrowserHistoryItems(t *testing.T) {
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		".config/google-chrome/Default/History",
+		".config/google-chrome/Default/Bookmarks",
+		".config/google-chrome/Default/Cookies",
+		".mozilla/firefox/abcd.default/places.sqlite",
+		".mozilla/firefox/abcd.default/cookies.sqlite",
+	)
+	ini := "[Profile0]\nName=default\nIsRelative=1\nPath=abcd.default\n"
+	writeTestFile(t, filepath.Join(home, ".mozilla/firefox/profiles.ini"), ini)
+	chrome := filepath.Join(home, ".config/google-chrome/Default")
+	firefox := filepath.Join(home, ".mozilla/firefox/abcd.default")
+
+	history := browserHistoryItems(home, t.TempDir())
+	expected := []privacyItem{
+		{path: filepath.Join(chrome, "History")},
+		{path: filepath.Join(firefox, "places.sqlite"), sql: firefoxHistorySQL},
+	}
+	if !reflect.DeepEqual(history, expected) {
+		t.Errorf("Expected %v, got %v", expected, history)
+	}
+
+	cookies := browserCookieItems(home, t.TempDir())
+	expected = []privacyItem{
+		{path: filepath.Join(chrome, "Cookies")},
+		{path: filepath.Join(firefox, "cookies.sqlite")},
+	}
+	if !reflect.DeepEqual(cookies, expected) {
+		t.Errorf("Expected %v, got %v", expected, cookies)
+	}
+}
+
+func TestShellHistoryItems(t *testing.T) {
+	home := t.TempDir()
+	writeTestFiles(t, home, ".bash_history", ".zsh_history/not-a-file")
+
+	items := shellHistoryItems(home)
+	expected := []privacyItem{{path: filepath.Join(home, ".bash_history")}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected %v, got %v", expected, items)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"/home/a b/x": `'/home/a b/x'`,
+		"it's":        `'it'\''s'`,
+	}
+	for input, expected := range tests {
+		if got := shellQuote(input); got != expected {
+			t.Errorf("shellQuote(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+func TestPrivacyCleanersAreOptIn(t *testing.T) {
+	privacy := GetCleanupTypesInCategory(CategoryPrivacy)
+	if !slices.Contains(privacy, "privacy_shell_history") {
+		t.Errorf("Expected privacy_shell_history in %v", privacy)
+	}
+	defaults := GetDefaultCleanupTypes()
+	for _, name := range privacy {
+		if slices.Contains(defaults, name) {
+			t.Errorf("Expected %s to be left out of the default cleanup types", name)
+		}
+	}
+	if !slices.Contains(defaults, "thumbnails") {
+		t.Errorf("Expected thumbnails in the default cleanup types")
+	}
+	if GetCleanupTypesInCategory("") != nil {
+		t.Errorf("Expected no cleanup types for an empty category")
+	}
+}
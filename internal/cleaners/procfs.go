@@ -0,0 +1,224 @@
+package cleaners
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const procDir = "/proc"
+
+var (
+	openFilesOnce  sync.Once
+	openFilesIndex map[string][]int
+)
+
+func openFiles() map[string][]int {
+	openFilesOnce.Do(func() {
+		openFilesIndex = scanOpenFiles(procDir)
+	})
+	return openFilesIndex
+}
+
+func scanOpenFiles(proc string) map[string][]int {
+	open := make(map[string][]int)
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return open
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(proc, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !filepath.IsAbs(target) {
+				continue
+			}
+			open[target] = append(open[target], pid)
+		}
+		for _, path := range mappedFiles(filepath.Join(proc, entry.Name(), "maps")) {
+			if pids := open[path]; len(pids) == 0 || pids[len(pids)-1] != pid {
+				open[path] = append(open[path], pid)
+			}
+		}
+	}
+	return open
+}
+
+func mappedFiles(mapsFile string) []string {
+	f, err := os.Open(mapsFile)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var paths []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The address, permission, offset, device and inode columns never
+		// contain a slash, so the path starts at the first one.
+		line := scanner.Text()
+		i := strings.IndexByte(line, '/')
+		if i < 0 {
+			continue
+		}
+		path := line[i:]
+		if strings.HasSuffix(path, " (deleted)") || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Deleted targets that are not files on disk, such as memfd and shared memory.
+var deletedOpenIgnoredPrefixes = []string{"/memfd:", "/SYSV", "/dev/shm/", "/dev/zero"}
+
+type deletedOpenFile struct {
+	path    string
+	fdPath  string
+	size    int64
+	holders []string
+}
+
+func processName(proc string, pid int) string {
+	data, err := os.ReadFile(filepath.Join(proc, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func scanDeletedOpenFiles(proc string) []deletedOpenFile {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return nil
+	}
+
+	type inode struct{ dev, ino uint64 }
+	byInode := make(map[inode]*deletedOpenFile)
+	var order []inode
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(proc, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			fdPath := filepath.Join(fdDir, fd.Name())
+			target, err := os.Readlink(fdPath)
+			if err != nil {
+				continue
+			}
+			path, deleted := strings.CutSuffix(target, " (deleted)")
+			if !deleted || !filepath.IsAbs(path) || hasAnyPrefix(path, deletedOpenIgnoredPrefixes) {
+				continue
+			}
+			info, err := os.Stat(fdPath)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				continue
+			}
+
+			key := inode{uint64(stat.Dev), stat.Ino}
+			f, seen := byInode[key]
+			if !seen {
+				f = &deletedOpenFile{path: path, fdPath: fdPath, size: info.Size()}
+				byInode[key] = f
+				order = append(order, key)
+			}
+			holder := fmt.Sprintf("%s[%d]", processName(proc, pid), pid)
+			if n := len(f.holders); n == 0 || f.holders[n-1] != holder {
+				f.holders = append(f.holders, holder)
+			}
+		}
+	}
+
+	files := make([]deletedOpenFile, 0, len(order))
+	for _, key := range order {
+		files = append(files, *byInode[key])
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].size > files[j].size })
+	return files
+}
+
+func processAlive(proc string, pid int) bool {
+	_, err := os.Stat(filepath.Join(proc, strconv.Itoa(pid)))
+	return err == nil
+}
+
+// findProcess matches argv[0], as comm is cut to 15 characters.
+func findProcess(proc string, names []string) int {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return 0
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(proc, entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+		for _, name := range names {
+			if filepath.Base(argv0) == name {
+				return pid
+			}
+		}
+	}
+	return 0
+}
+
+func findProcessMentioning(proc, s string) int {
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return 0
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(proc, entry.Name(), "cmdline"))
+		if err == nil && strings.Contains(strings.ReplaceAll(string(cmdline), "\x00", " "), s) {
+			return pid
+		}
+	}
+	return 0
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
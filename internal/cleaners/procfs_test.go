@@ -0,0 +1,90 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFakeProc creates a fake /proc tree under dir where each pid holds the given fd targets open.
+func writeFakeProc(t *testing.T, dir string, fds map[string][]string) {
+	t.Helper()
+	for pid, targets := range fds {
+		fdDir := filepath.Join(dir, pid, "fd")
+		if err := os.MkdirAll(fdDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i, target := range targets {
+			if err := os.Symlink(target, filepath.Join(fdDir, string(rune('0'+i)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestScanOpenFiles(t *testing.T) {
+	proc := t.TempDir()
+	writeFakeProc(t, proc, map[string][]string{
+		"100": {"/dev/null", "/var/log/syslog", "socket:[12345]"},
+		"200": {"/var/log/syslog", "pipe:[6789]"},
+	})
+	if err := os.MkdirAll(filepath.Join(proc, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	maps := `55d0c0a00000-55d0c0a28000 r--p 00000000 08:01 1312   /usr/bin/python3
+55d0c0a28000-55d0c0b00000 r-xp 00028000 08:01 1312   /usr/bin/python3
+7f1c2a000000-7f1c2a021000 rw-p 00000000 00:00 0      [heap]
+7f1c2b000000-7f1c2b100000 r--s 00000000 08:01 4242   /tmp/My Data/index.db
+7f1c2c000000-7f1c2c001000 r--p 00000000 08:01 5151   /tmp/gone.so (deleted)
+7f1c2d000000-7f1c2d021000 rw-p 00000000 00:00 0
+`
+	writeTestFile(t, filepath.Join(proc, "200", "maps"), maps)
+
+	open := scanOpenFiles(proc)
+	if len(open["/var/log/syslog"]) != 2 {
+		t.Errorf("Expected /var/log/syslog to be held by 2 processes, got %v", open["/var/log/syslog"])
+	}
+	if _, ok := open["socket:[12345]"]; ok {
+		t.Error("Expected sockets to be ignored")
+	}
+	if pids := open["/usr/bin/python3"]; len(pids) != 1 || pids[0] != 200 {
+		t.Errorf("Expected /usr/bin/python3 to be mapped once by pid 200, got %v", pids)
+	}
+	if _, ok := open["/tmp/My Data/index.db"]; !ok {
+		t.Error("Expected mapped paths containing spaces to be indexed")
+	}
+	if len(open) != 4 {
+		t.Errorf("Expected 4 open paths, got %d: %v", len(open), open)
+	}
+}
+
+func TestScanDeletedOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	deleted := filepath.Join(dir, "cache.db (deleted)")
+	if err := os.WriteFile(deleted, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	small := filepath.Join(dir, "small.tmp (deleted)")
+	if err := os.WriteFile(small, make([]byte, 16), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	proc := filepath.Join(dir, "proc")
+	writeFakeProc(t, proc, map[string][]string{
+		"100": {deleted, deleted, "/memfd:wayland (deleted)"},
+		"200": {deleted, small, "/var/log/syslog"},
+	})
+	writeTestFile(t, filepath.Join(proc, "100", "comm"), "chrome\n")
+
+	files := scanDeletedOpenFiles(proc)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 deleted files, got %d: %+v", len(files), files)
+	}
+	if files[0].path != filepath.Join(dir, "cache.db") || files[0].size != 4096 {
+		t.Errorf("Unexpected largest deleted file: %+v", files[0])
+	}
+	if strings.Join(files[0].holders, ",") != "chrome[100],?[200]" {
+		t.Errorf("Unexpected holders: %v", files[0].holders)
+	}
+}
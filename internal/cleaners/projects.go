@@ -0,0 +1,226 @@
+package cleaners
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+// projectArtifact.built tells artifacts from sources kept in directories such as build.
+type projectArtifact struct {
+	name        string
+	built       func(dir string) bool
+	ignoredOnly bool
+}
+
+var projectMarkers = map[string][]projectArtifact{
+	"package.json":     {{"node_modules", nil, false}, {"dist", nil, true}, {"build", hasAnyFile("asset-manifest.json"), false}, {".next", nil, false}},
+	"Cargo.toml":       {{"target", nil, false}},
+	"pyproject.toml":   {{".venv", nil, false}, {"build", isPythonBuild, false}, {"dist", hasAnyFile("*.whl", "*.tar.gz"), false}},
+	"pom.xml":          {{"target", nil, false}},
+	"build.gradle":     {{"build", isGradleBuild, false}, {".gradle", nil, false}},
+	"build.gradle.kts": {{"build", isGradleBuild, false}, {".gradle", nil, false}},
+	"CMakeLists.txt":   {{"build", hasAnyFile("CMakeCache.txt"), false}},
+	"go.mod":           {{"build", onlyBinaries, false}, {"dist", isGoReleaserDist, false}},
+}
+
+func hasAnyFile(patterns ...string) func(dir string) bool {
+	return func(dir string) bool {
+		for _, pattern := range patterns {
+			if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isPythonBuild looks for the bdist.* and egg-info setuptools leaves behind.
+func isPythonBuild(dir string) bool {
+	root := filepath.Dir(dir)
+	return hasAnyFile("bdist.*")(dir) || hasAnyFile("*.egg-info", "src/*.egg-info")(root)
+}
+
+func isGradleBuild(dir string) bool {
+	info, err := os.Stat(filepath.Join(filepath.Dir(dir), ".gradle"))
+	return err == nil && info.IsDir()
+}
+
+func isGoReleaserDist(dir string) bool {
+	return hasAnyFile("metadata.json", "artifacts.json")(dir) || onlyBinaries(dir)
+}
+
+var binaryMagics = [][]byte{{0x7f, 'E', 'L', 'F'}, {0xcf, 0xfa, 0xed, 0xfe}, {0xce, 0xfa, 0xed, 0xfe}, {'M', 'Z'}}
+
+func onlyBinaries(dir string) bool {
+	found, other := false, false
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || (!entry.IsDir() && !isBinary(path)) {
+			other = true
+			return fs.SkipAll
+		}
+		found = found || !entry.IsDir()
+		return nil
+	})
+	return found && !other
+}
+
+func isBinary(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 4)
+	n, _ := io.ReadFull(f, head)
+	for _, magic := range binaryMagics {
+		if bytes.HasPrefix(head[:n], magic) {
+			return true
+		}
+	}
+	return false
+}
+
+type project struct {
+	root        string
+	markers     []string
+	artifacts   []string
+	ignoredOnly []string
+}
+
+// findProjects skips hidden directories and artifact directories.
+func findProjects(root string) []project {
+	var projects []project
+	var scan func(dir string)
+	scan = func(dir string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		p := project{root: dir}
+		artifacts := make(map[string]bool)
+		ignoredOnly := make(map[string]bool)
+		for _, entry := range entries {
+			candidates, ok := projectMarkers[entry.Name()]
+			if !ok || !entry.Type().IsRegular() {
+				continue
+			}
+			p.markers = append(p.markers, entry.Name())
+			for _, artifact := range candidates {
+				if artifact.built != nil && !artifact.built(filepath.Join(dir, artifact.name)) {
+					continue
+				}
+				// An artifact is only limited to working trees when every
+				// marker calling for it says so.
+				if !artifacts[artifact.name] {
+					artifacts[artifact.name] = true
+					ignoredOnly[artifact.name] = artifact.ignoredOnly
+					p.artifacts = append(p.artifacts, artifact.name)
+				} else if !artifact.ignoredOnly {
+					ignoredOnly[artifact.name] = false
+				}
+			}
+		}
+		if len(p.markers) > 0 {
+			sort.Strings(p.artifacts)
+			for _, name := range p.artifacts {
+				if ignoredOnly[name] {
+					p.ignoredOnly = append(p.ignoredOnly, name)
+				}
+			}
+			projects = append(projects, p)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || artifacts[entry.Name()] {
+				continue
+			}
+			scan(filepath.Join(dir, entry.Name()))
+		}
+	}
+	scan(root)
+	return projects
+}
+
+// lastSourceChange leaves out hidden and artifact directories.
+func lastSourceChange(p project) time.Time {
+	artifacts := make(map[string]bool)
+	for _, name := range p.artifacts {
+		artifacts[name] = true
+	}
+	var latest time.Time
+	filepath.WalkDir(p.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != p.root && (strings.HasPrefix(entry.Name(), ".") || artifacts[entry.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
+
+func projectArtifactDirs(p project) []string {
+	var dirs []string
+	for _, name := range p.artifacts {
+		path := filepath.Join(p.root, name)
+		if info, err := os.Lstat(path); err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs
+}
+
+func cleanProjectArtifactsIn(root string, maxAge time.Duration, now time.Time, r *artifactRemover) {
+	for _, p := range findProjects(root) {
+		dirs := projectArtifactDirs(p)
+		if len(dirs) == 0 {
+			continue
+		}
+		changed := lastSourceChange(p)
+		if now.Sub(changed) < maxAge {
+			continue
+		}
+
+		var sizes []string
+		for _, dir := range dirs {
+			sizes = append(sizes, fmt.Sprintf("%s %s", filepath.Base(dir), utils.FormatBytes(uint64(treeSize(dir)))))
+		}
+		fmt.Printf("%s (%s, unchanged for %d days): %s\n", p.root, strings.Join(p.markers, ", "),
+			int(now.Sub(changed).Hours()/24), strings.Join(sizes, ", "))
+		for _, dir := range dirs {
+			if slices.Contains(p.ignoredOnly, filepath.Base(dir)) {
+				r.removeIgnored(dir)
+			} else {
+				r.remove(dir)
+			}
+		}
+	}
+}
+
+func cleanProjectArtifacts() error {
+	homes := userHomes(passwdFile)
+	r := newArtifactRemover(newDeleter(), userNames(homes))
+	maxAge := time.Duration(options.ProjectMaxAgeDays) * 24 * time.Hour
+	for _, home := range homes {
+		cleanProjectArtifactsIn(home.dir, maxAge, time.Now(), r)
+	}
+	r.report("project artifact file(s)")
+	return nil
+}
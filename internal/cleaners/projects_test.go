@@ -0,0 +1,124 @@
+package cleaners
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ageTree sets the modification time of everything below dir to when.
+func ageTree(t *testing.T, dir string, when time.Time) {
+	t.Helper()
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, when, when)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeBinary writes a file starting with the ELF magic number.
+func writeBinary(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("\x7fELF\x02\x01\x01"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindProjects(t *testing.T) {
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"code/web/package.json",
+		"code/web/node_modules/lib/package.json",
+		"code/web/packages/ui/package.json",
+		"code/tool/Cargo.toml",
+		"code/tool/pyproject.toml",
+		"code/tool/build/scripts/release.sh",
+		"code/native/CMakeLists.txt",
+		"code/native/build/CMakeCache.txt",
+		"code/cli/go.mod",
+		"code/cli/dist/README.md",
+		".cache/pip/pyproject.toml",
+		"notes/todo.txt",
+	)
+	writeBinary(t, filepath.Join(home, "code/cli/build/cli"))
+
+	projects := findProjects(home)
+	expected := []project{
+		{root: filepath.Join(home, "code/cli"), markers: []string{"go.mod"}, artifacts: []string{"build"}},
+		{root: filepath.Join(home, "code/native"), markers: []string{"CMakeLists.txt"}, artifacts: []string{"build"}},
+		{root: filepath.Join(home, "code/tool"), markers: []string{"Cargo.toml", "pyproject.toml"}, artifacts: []string{".venv", "target"}},
+		{root: filepath.Join(home, "code/web"), markers: []string{"package.json"}, artifacts: []string{".next", "dist", "node_modules"}, ignoredOnly: []string{"dist"}},
+		{root: filepath.Join(home, "code/web/packages/ui"), markers: []string{"package.json"}, artifacts: []string{".next", "dist", "node_modules"}, ignoredOnly: []string{"dist"}},
+	}
+	if !reflect.DeepEqual(projects, expected) {
+		t.Errorf("Expected %v, got %v", expected, projects)
+	}
+}
+
+func TestCleanProjectArtifacts(t *testing.T) {
+	now := time.Now()
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"old-web/package.json",
+		"old-web/src/index.js",
+		"old-web/node_modules/lib/index.js",
+		"old-web/.next/cache/x",
+		"old-web/dist/index.js",
+		"recent-rust/Cargo.toml",
+		"recent-rust/src/main.rs",
+		"recent-rust/target/debug/app",
+		"old-python/pyproject.toml",
+		"old-python/.venv/bin/python",
+		"touched-build/package.json",
+		"touched-build/node_modules/lib/index.js",
+		"old-go/go.mod",
+		"old-go/main.go",
+		"old-go/build/package/Dockerfile",
+		"old-go/dist/.goreleaser.yml",
+		"old-release/go.mod",
+		"old-release/main.go",
+		"old-release/dist/metadata.json",
+		"old-gradle/build.gradle",
+		"old-gradle/.gradle/8.5/fileHashes.bin",
+		"old-gradle/build/libs/app.jar",
+		"old-cmake/CMakeLists.txt",
+		"old-cmake/build/toolchain.cmake",
+	)
+	ageTree(t, filepath.Join(home, "old-web"), now.Add(-200*24*time.Hour))
+	ageTree(t, filepath.Join(home, "old-python"), now.Add(-100*24*time.Hour))
+	ageTree(t, filepath.Join(home, "recent-rust"), now.Add(-100*24*time.Hour))
+	writeBinary(t, filepath.Join(home, "old-release/build/app"))
+	writeBinary(t, filepath.Join(home, "old-release/dist/app_linux_amd64/app"))
+	ageTree(t, filepath.Join(home, "old-go"), now.Add(-100*24*time.Hour))
+	ageTree(t, filepath.Join(home, "old-release"), now.Add(-100*24*time.Hour))
+	ageTree(t, filepath.Join(home, "old-gradle"), now.Add(-100*24*time.Hour))
+	ageTree(t, filepath.Join(home, "old-cmake"), now.Add(-100*24*time.Hour))
+	if err := os.Chtimes(filepath.Join(home, "recent-rust/src/main.rs"), now, now); err != nil {
+		t.Fatal(err)
+	}
+	// Rebuilding refreshes the artifacts, not the source.
+	ageTree(t, filepath.Join(home, "touched-build"), now.Add(-100*24*time.Hour))
+	ageTree(t, filepath.Join(home, "touched-build/node_modules"), now)
+
+	d := &deleter{}
+	cleanProjectArtifactsIn(home, 90*24*time.Hour, now, newArtifactRemover(d, nil))
+
+	assertFilesRemoved(t, home, "old-web/node_modules", "old-web/.next", "old-python/.venv", "touched-build/node_modules", "old-gradle/build", "old-gradle/.gradle",
+		"old-release/build", "old-release/dist")
+	// A dist outside a working tree may be a committed library build.
+	assertFilesExist(t, home, "old-web/package.json", "old-web/src/index.js", "old-web/dist/index.js", "old-python/pyproject.toml", "recent-rust/target/debug/app",
+		"old-go/build/package/Dockerfile", "old-go/dist/.goreleaser.yml", "old-release/main.go", "old-cmake/build/toolchain.cmake")
+	if d.removed != 9 {
+		t.Errorf("Expected 9 files removed, got %d", d.removed)
+	}
+}
@@ -0,0 +1,194 @@
+package cleaners
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const recentFilesPath = ".local/share/recently-used.xbel"
+
+type recentBookmark struct {
+	href       string
+	when       time.Time
+	start, end int
+}
+
+func parseXBEL(data []byte) ([]recentBookmark, error) {
+	var bookmarks []recentBookmark
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return bookmarks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "bookmark" {
+			continue
+		}
+
+		bookmark := recentBookmark{start: start}
+		for _, attr := range element.Attr {
+			switch attr.Name.Local {
+			case "href":
+				bookmark.href = attr.Value
+			case "added", "modified", "visited":
+				if when, err := time.Parse(time.RFC3339, attr.Value); err == nil && when.After(bookmark.when) {
+					bookmark.when = when
+				}
+			}
+		}
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		bookmark.end = int(decoder.InputOffset())
+		bookmarks = append(bookmarks, bookmark)
+	}
+}
+
+type recentTrim struct {
+	missing, expired, excess int
+}
+
+func (t recentTrim) total() int {
+	return t.missing + t.expired + t.excess
+}
+
+// selectRecentDrops drops missing local files, entries older than maxAge and the oldest beyond maxEntries.
+func selectRecentDrops(bookmarks []recentBookmark, maxAge time.Duration, maxEntries int, now time.Time) ([]bool, recentTrim) {
+	drop := make([]bool, len(bookmarks))
+	var trim recentTrim
+	var kept []int
+	for i, bookmark := range bookmarks {
+		switch {
+		case localFileGone(bookmark.href):
+			drop[i] = true
+			trim.missing++
+		case maxAge > 0 && now.Sub(bookmark.when) > maxAge:
+			drop[i] = true
+			trim.expired++
+		default:
+			kept = append(kept, i)
+		}
+	}
+
+	if maxEntries > 0 && len(kept) > maxEntries {
+		sort.SliceStable(kept, func(a, b int) bool {
+			return bookmarks[kept[a]].when.After(bookmarks[kept[b]].when)
+		})
+		for _, i := range kept[maxEntries:] {
+			drop[i] = true
+			trim.excess++
+		}
+	}
+	return drop, trim
+}
+
+func localFileGone(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return false
+	}
+	if _, err := os.Lstat(u.Path); !os.IsNotExist(err) {
+		return false
+	}
+	if hasAnyPrefix(u.Path, removableMediaRoots) {
+		if _, err := os.Stat(filepath.Dir(u.Path)); os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+func cutBookmarks(data []byte, bookmarks []recentBookmark, drop []bool) []byte {
+	var out bytes.Buffer
+	last := 0
+	for i, bookmark := range bookmarks {
+		if !drop[i] {
+			continue
+		}
+		start, end := bookmark.start, bookmark.end
+		for start > last && (data[start-1] == ' ' || data[start-1] == '\t') {
+			start--
+		}
+		if end < len(data) && data[end] == '\n' {
+			end++
+		}
+		out.Write(data[last:start])
+		last = end
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
+
+func trimRecentFilesIn(paths []string, maxAge time.Duration, maxEntries int, now time.Time, confirm utils.ConfirmFunc) error {
+	type pending struct {
+		path    string
+		data    []byte
+		trimmed []byte
+		info    os.FileInfo
+	}
+	var trims []pending
+
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Warning: Failed to read %s: %v\n", path, err)
+			continue
+		}
+		bookmarks, err := parseXBEL(data)
+		if err != nil {
+			fmt.Printf("Warning: Skipping %s: %v\n", path, err)
+			continue
+		}
+		drop, trim := selectRecentDrops(bookmarks, maxAge, maxEntries, now)
+		if trim.total() == 0 {
+			continue
+		}
+		fmt.Printf("  %s: dropping %d of %d entries (%d missing, %d expired, %d over the limit of %d)\n",
+			path, trim.total(), len(bookmarks), trim.missing, trim.expired, trim.excess, maxEntries)
+		trims = append(trims, pending{path, data, cutBookmarks(data, bookmarks, drop), info})
+	}
+
+	if len(trims) == 0 {
+		fmt.Println("Nothing to trim")
+		return nil
+	}
+	if !confirm(fmt.Sprintf("Trim the %d recent files list(s) listed above", len(trims))) {
+		fmt.Println("Skipping privacy_recent_files")
+		return nil
+	}
+
+	for _, t := range trims {
+		if err := writeFileAtomic(t.path, t.trimmed, t.info); err != nil {
+			fmt.Printf("Warning: Failed to update %s: %v\n", t.path, err)
+			continue
+		}
+		recordReclaimed(int64(len(t.data) - len(t.trimmed)))
+	}
+	return nil
+}
+
+func trimRecentFiles() error {
+	var paths []string
+	for _, home := range userHomes(passwdFile) {
+		paths = append(paths, filepath.Join(home.dir, recentFilesPath))
+	}
+	return trimRecentFilesIn(paths, time.Duration(options.RecentFilesMaxAgeDays)*24*time.Hour, options.RecentFilesMaxEntries, time.Now(), utils.Confirm)
+}
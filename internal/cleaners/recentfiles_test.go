@@ -0,0 +1,95 @@
+package cleaners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func xbelBookmark(href string, visited time.Time) string {
+	return fmt.Sprintf(`  <bookmark href="%s" added="%s" modified="%s" visited="%s">
+    <info>
+      <metadata owner="http://freedesktop.org">
+        <mime:mime-type type="text/plain"/>
+      </metadata>
+    </info>
+  </bookmark>
+`, href, visited.Add(-time.Hour).Format(time.RFC3339), visited.Format(time.RFC3339), visited.Format(time.RFC3339))
+}
+
+func xbelFile(bookmarks ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<xbel version="1.0"
+      xmlns:bookmark="http://www.freedesktop.org/standards/desktop-bookmarks"
+      xmlns:mime="http://www.freedesktop.org/standards/shared-mime-info"
+>
+` + strings.Join(bookmarks, "") + "</xbel>"
+}
+
+func TestTrimRecentFiles(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "kept.txt", "old.txt", "newest.txt", "excess.txt")
+	uri := func(name string) string { return "file://" + filepath.Join(dir, name) }
+
+	kept := xbelBookmark(uri("kept.txt"), now.Add(-48*time.Hour))
+	newest := xbelBookmark(uri("newest.txt"), now.Add(-time.Hour))
+	remote := xbelBookmark("sftp://host/file.txt", now.Add(-2*time.Hour))
+	input := xbelFile(
+		kept,
+		xbelBookmark(uri("missing.txt"), now.Add(-time.Hour)),
+		xbelBookmark(uri("old.txt"), now.Add(-40*24*time.Hour)),
+		newest,
+		remote,
+		xbelBookmark(uri("excess.txt"), now.Add(-72*time.Hour)),
+	)
+	path := filepath.Join(dir, "recently-used.xbel")
+	if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := parseXBEL([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(bookmarks) != 6 {
+		t.Fatalf("Expected 6 bookmarks, got %d", len(bookmarks))
+	}
+	_, trim := selectRecentDrops(bookmarks, 30*24*time.Hour, 3, now)
+	if trim != (recentTrim{missing: 1, expired: 1, excess: 1}) {
+		t.Errorf("Unexpected trim counts: %+v", trim)
+	}
+
+	if err := trimRecentFilesIn([]string{path}, 30*24*time.Hour, 3, now, func(string) bool { return false }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != input {
+		t.Errorf("Expected the file to be left alone when declined")
+	}
+
+	if err := trimRecentFilesIn([]string{path}, 30*24*time.Hour, 3, now, func(string) bool { return true }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := xbelFile(kept, newest, remote); string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode to be kept, got %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 5 {
+		t.Errorf("Expected no temporary files to be left, got %d entries", len(entries))
+	}
+}
+
+func TestParseXBELInvalid(t *testing.T) {
+	if _, err := parseXBEL([]byte(`<xbel><bookmark href="file:///a">`)); err == nil {
+		t.Errorf("Expected an error for a truncated file")
+	}
+}
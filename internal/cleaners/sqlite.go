@@ -0,0 +1,175 @@
+package cleaners
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+var sqliteSidecars = []string{"-journal", "-wal", "-shm"}
+
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+var (
+	firefoxDatabases     = []string{"places.sqlite", "cookies.sqlite", "favicons.sqlite", "formhistory.sqlite", "permissions.sqlite", "content-prefs.sqlite", "webappsstore.sqlite"}
+	chromiumDatabases    = []string{"History", "Cookies", "Network/Cookies", "Favicons", "Web Data", "Top Sites", "Shortcuts"}
+	thunderbirdDatabases = []string{"global-messages-db.sqlite", "places.sqlite", "cookies.sqlite", "favicons.sqlite", "formhistory.sqlite"}
+)
+
+type sqliteApp struct {
+	name      string
+	patterns  []string
+	processes []string
+}
+
+var sqliteApps = []sqliteApp{
+	{"GNOME Tracker", []string{".cache/tracker3/files/*.db", ".cache/tracker/*.db"}, []string{"tracker-miner-fs-3", "tracker-miner-fs", "tracker-extract-3", "localsearch-3"}},
+	{"Zeitgeist", []string{".local/share/zeitgeist/activity.sqlite"}, []string{"zeitgeist-daemon"}},
+	{"Evolution", []string{".cache/evolution/mail/*/folders.db"}, []string{"evolution"}},
+	{"Shotwell", []string{".local/share/shotwell/data/photo.db"}, []string{"shotwell"}},
+}
+
+type sqliteDatabase struct {
+	app  string
+	path string
+	pid  int
+}
+
+func isSQLiteDatabase(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(f, header)
+	return err == nil && bytes.Equal(header, sqliteHeader)
+}
+
+func findSQLiteDatabases(home, proc string) []sqliteDatabase {
+	var dbs []sqliteDatabase
+	add := func(app, path string, pid int) {
+		if isSQLiteDatabase(path) {
+			dbs = append(dbs, sqliteDatabase{app: app, path: path, pid: pid})
+		}
+	}
+
+	for _, profile := range findBrowserProfiles(home, proc) {
+		names := chromiumDatabases
+		if profile.firefox {
+			names = firefoxDatabases
+		}
+		for _, name := range names {
+			add(profile.browser, filepath.Join(profile.dir, name), profile.pid)
+		}
+	}
+	for _, profile := range mozillaProfiles(home, proc, thunderbirdInstalls) {
+		for _, name := range thunderbirdDatabases {
+			add(profile.browser, filepath.Join(profile.dir, name), profile.pid)
+		}
+	}
+
+	for _, app := range sqliteApps {
+		var paths []string
+		for _, pattern := range app.patterns {
+			matches, _ := filepath.Glob(filepath.Join(home, pattern))
+			paths = append(paths, matches...)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		pid := findProcess(proc, app.processes)
+		for _, path := range paths {
+			add(app.name, path, pid)
+		}
+	}
+	return dbs
+}
+
+func sqliteSize(path string) int64 {
+	size := fileSize(path)
+	for _, suffix := range sqliteSidecars {
+		size += fileSize(path + suffix)
+	}
+	return size
+}
+
+// restoreSidecarOwnership hands sidecars sqlite3 created as root back to the database owner.
+func restoreSidecarOwnership(db string) {
+	info, err := os.Stat(db)
+	if err != nil {
+		return
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	for _, suffix := range sqliteSidecars {
+		os.Lchown(db+suffix, int(stat.Uid), int(stat.Gid))
+	}
+}
+
+func vacuumDatabases(dbs []sqliteDatabase, open map[string][]int) {
+	var total int64
+	vacuumed := 0
+	for _, db := range dbs {
+		if db.pid != 0 {
+			fmt.Printf("Skipping %s: %s is running (pid %d)\n", db.path, db.app, db.pid)
+			continue
+		}
+		if pid := openBy(open, db.path, db.path+"-wal"); pid != 0 {
+			fmt.Printf("Skipping %s: in use (pid %d)\n", db.path, pid)
+			continue
+		}
+
+		before := sqliteSize(db.path)
+		command := fmt.Sprintf("sqlite3 %s 'VACUUM;'", shellQuote(db.path))
+		err := utils.Runner.RunWithIndicator(command, "Vacuuming "+db.path+"...")
+		restoreSidecarOwnership(db.path)
+		if err != nil {
+			fmt.Printf("Warning: Failed to vacuum %s: %v\n", db.path, err)
+			continue
+		}
+		vacuumed++
+		after := sqliteSize(db.path)
+		freed := max(before-after, 0)
+		total += freed
+		fmt.Printf("%s (%s): %s -> %s, %s reclaimed\n", db.path, db.app,
+			utils.FormatBytes(uint64(before)), utils.FormatBytes(uint64(after)), utils.FormatBytes(uint64(freed)))
+	}
+	recordReclaimed(total)
+	fmt.Printf("Vacuumed %d database(s), %s reclaimed\n", vacuumed, utils.FormatBytes(uint64(total)))
+}
+
+func openBy(open map[string][]int, paths ...string) int {
+	for _, path := range paths {
+		if pids := open[path]; len(pids) > 0 {
+			return pids[0]
+		}
+	}
+	return 0
+}
+
+func vacuumSQLiteDatabases(commandExists utils.CommandExistsFunc) func() error {
+	return func() error {
+		if !commandExists("sqlite3") {
+			fmt.Println("SQLite vacuum: Skipped (sqlite3 not installed)")
+			return nil
+		}
+		var dbs []sqliteDatabase
+		for _, home := range userHomes(passwdFile) {
+			dbs = append(dbs, findSQLiteDatabases(home.dir, procDir)...)
+		}
+		vacuumDatabases(dbs, openFiles())
+		return nil
+	}
+}
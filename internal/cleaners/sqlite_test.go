@@ -0,0 +1,86 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestDatabase creates a file starting with the SQLite header, padded
+// to size bytes.
+func writeTestDatabase(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, size)
+	copy(data, sqliteHeader)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindSQLiteDatabases(t *testing.T) {
+	home := t.TempDir()
+	proc := t.TempDir()
+	writeTestDatabase(t, filepath.Join(home, ".config/chromium/Default/History"), 4096)
+	writeTestDatabase(t, filepath.Join(home, ".thunderbird/xyz.default/global-messages-db.sqlite"), 4096)
+	writeTestDatabase(t, filepath.Join(home, ".cache/tracker3/files/meta.db"), 4096)
+	writeTestFiles(t, home, ".config/chromium/Default/Preferences", ".config/chromium/Default/Cookies")
+	writeTestFile(t, filepath.Join(home, ".thunderbird/profiles.ini"), "[Profile0]\nName=default\nIsRelative=1\nPath=xyz.default\n")
+	writeTestFile(t, filepath.Join(proc, "77/cmdline"), "/usr/libexec/tracker-miner-fs-3\x00--flag\x00")
+
+	dbs := findSQLiteDatabases(home, proc)
+	expected := []sqliteDatabase{
+		{app: "Chromium", path: filepath.Join(home, ".config/chromium/Default/History")},
+		{app: "Thunderbird", path: filepath.Join(home, ".thunderbird/xyz.default/global-messages-db.sqlite")},
+		{app: "GNOME Tracker", path: filepath.Join(home, ".cache/tracker3/files/meta.db"), pid: 77},
+	}
+	if len(dbs) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, dbs)
+	}
+	for i := range expected {
+		if dbs[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], dbs[i])
+		}
+	}
+}
+
+func TestVacuumDatabases(t *testing.T) {
+	mock := setupTest()
+	dir := t.TempDir()
+	idle := filepath.Join(dir, "idle.sqlite")
+	running := filepath.Join(dir, "running.sqlite")
+	open := filepath.Join(dir, "open.sqlite")
+	for _, path := range []string{idle, running, open} {
+		writeTestDatabase(t, path, 8192)
+	}
+	// Simulate VACUUM shrinking the database.
+	mock.RunWithIndicatorFunc = func(command, message string) error {
+		return os.Truncate(idle, 4096)
+	}
+
+	vacuumDatabases([]sqliteDatabase{
+		{app: "Idle", path: idle},
+		{app: "Running", path: running, pid: 42},
+		{app: "Open", path: open},
+	}, map[string][]int{open + "-wal": {43}})
+
+	if len(mock.Commands) != 1 || !strings.HasPrefix(mock.Commands[0], "sqlite3 '"+idle+"'") {
+		t.Errorf("Expected only %s to be vacuumed, got %v", idle, mock.Commands)
+	}
+	if size := fileSize(idle); size != 4096 {
+		t.Errorf("Expected idle database to be vacuumed, got size %d", size)
+	}
+}
+
+func TestVacuumSQLiteDatabasesWithoutSqlite(t *testing.T) {
+	mock := setupTest()
+	if err := vacuumSQLiteDatabases(func(string) bool { return false })(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(mock.Commands) != 0 {
+		t.Errorf("Expected no commands, got %v", mock.Commands)
+	}
+}
@@ -2,17 +2,29 @@ package cleaners
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/cosmix/broom/internal/utils"
 )
 
+const (
+	crashDir    = "/var/crash"
+	coredumpDir = "/var/lib/systemd/coredump"
+	tempMaxAge  = 10 * 24 * time.Hour
+)
+
 func init() {
 	registerCleanup("kernels", Cleaner{CleanupFunc: removeOldKernels, RequiresConfirmation: false})
-	registerCleanup("apt", Cleaner{CleanupFunc: clearApt, RequiresConfirmation: false})
+	registerCleanup("apt", Cleaner{CleanupFunc: clearApt, RequiresConfirmation: true})
 	registerCleanup("logs", Cleaner{CleanupFunc: removeOldLogs, RequiresConfirmation: true})
 	registerCleanup("crash", Cleaner{CleanupFunc: removeCrashReports, RequiresConfirmation: true})
 	registerCleanup("temp", Cleaner{CleanupFunc: removeTemp, RequiresConfirmation: false})
 	registerCleanup("journal", Cleaner{CleanupFunc: cleanJournalLogs, RequiresConfirmation: true})
+	registerCleanup("deleted_open", Cleaner{CleanupFunc: reclaimDeletedOpenFiles, RequiresConfirmation: true})
 }
 
 func removeOldKernels() error {
@@ -20,52 +32,152 @@ func removeOldKernels() error {
 }
 
 func clearApt() error {
+	return clearAptWith(dpkgStatusFile, aptArchivesDir)
+}
+
+func clearAptWith(statusFile, archivesDir string) error {
 	err := utils.Runner.RunWithIndicator("apt-get autoremove -y", "Removing unnecessary packages...")
 	if err != nil {
 		return err
 	}
-	err = utils.Runner.RunWithIndicator("apt-get purge -y nano vim-tiny", "Removing non-critical packages...")
+	err = purgeResidualConfigs(statusFile)
 	if err != nil {
 		return err
 	}
-	return utils.Runner.RunWithIndicator("apt-get clean", "Clearing APT cache...")
+	return pruneAptCache(archivesDir, statusFile, options.KeepCacheVersions)
 }
 
-func removeOldLogs() error {
-	err := utils.Runner.RunWithIndicator("journalctl --vacuum-time=3d", "Clearing old journal logs...")
+func purgeResidualConfigs(statusFile string) error {
+	packages, err := readDpkgStatus(statusFile)
 	if err != nil {
-		return err
+		fmt.Printf("Warning: Unable to read dpkg status database: %v\n", err)
+		return nil
+	}
+
+	var residual []string
+	for _, pkg := range packages {
+		if !pkg.residualConfig() {
+			continue
+		}
+		residual = append(residual, pkg.qualifiedName())
+		fmt.Printf("  %s\n", pkg.name)
+		for _, conffile := range pkg.conffiles {
+			if _, err := os.Lstat(conffile); err == nil {
+				fmt.Printf("    %s\n", conffile)
+			}
+		}
 	}
-	return utils.Runner.RunFdOrFind("/var/log", "-type f -name \"*.log\" -mtime +30 -delete", "Removing old log files...", false)
+
+	if len(residual) == 0 {
+		fmt.Println("No residual-config packages found")
+		return nil
+	}
+
+	return utils.Runner.RunWithIndicator(fmt.Sprintf("dpkg --purge %s", strings.Join(residual, " ")), "Purging residual-config packages...")
+}
+
+func removeOldLogs() error {
+	return removeOldLogsIn(varLogDir, logrotateConfFile, logrotateConfDir)
+}
+
+func removeOldLogsIn(logDir, confFile, confDir string) error {
+	rules := loadLogrotateRules(confFile, confDir)
+	cleanRotatedLogs(logDir, rules, options.LogKeepRotations, options.LogAction, preferredCompression(utils.CommandExists), openFiles())
+	return nil
 }
 
 func removeCrashReports() error {
-	err := utils.Runner.RunWithIndicator("rm -rf /var/crash/*", "Removing crash reports...")
-	if err != nil {
-		return err
-	}
-	return utils.Runner.RunFdOrFind("/var/lib/systemd/coredump", "-type f -delete", "Removing core dumps...", false)
+	d := newDeleter()
+	maxAge := time.Duration(options.CoredumpMaxAgeDays) * 24 * time.Hour
+	removeCrashReportsIn(crashDir, coredumpDir, options.KeepCoredumps, maxAge, d)
+	d.report("crash report(s) and core dump(s)")
+	return nil
 }
 
 func removeTemp() error {
-	commands := []struct {
-		path string
-		args string
-		msg  string
-	}{
-		{"/tmp", "-type f -atime +10 -delete", "Removing old files in /tmp..."},
-		{"/var/tmp", "-type f -atime +10 -delete", "Removing old files in /var/tmp..."},
+	d := newDeleter()
+	dirs := []string{"/tmp", "/var/tmp"}
+	removeTempIn(dirs, loadTmpfilesRules(tmpfilesDirs), tempMaxAge, d)
+	removeStalePrivateTmp(dirs, currentBootID(procDir), activeSystemdUnits(), d)
+	d.report("old temporary file(s)")
+	return nil
+}
+
+// removeTempIn ages files by the tmpfiles.d rules of their directory, or defaultAge.
+func removeTempIn(dirs []string, rules []tmpfilesRule, defaultAge time.Duration, d *deleter) {
+	now := time.Now()
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || path == dir {
+				return nil
+			}
+			excluded, recursive := tmpfilesExcluded(rules, path)
+			if entry.IsDir() {
+				if recursive || tempSocketDirs[entry.Name()] || strings.HasPrefix(entry.Name(), privateTmpPrefix) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if excluded || !entry.Type().IsRegular() || isLockFile(entry.Name()) {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			age, never := tmpfilesAge(rules, path)
+			if never {
+				return nil
+			}
+			if age == 0 {
+				age = defaultAge
+			}
+			if now.Sub(lastUsed(info)) < age {
+				return nil
+			}
+			d.removeFile(path)
+			return nil
+		})
+	}
+}
+
+func reclaimDeletedOpenFiles() error {
+	return reclaimDeletedOpenFilesIn(procDir, utils.Confirm)
+}
+
+// reclaimDeletedOpenFilesIn confirms each truncation: databases, JVMs and browsers keep unlinked files on purpose.
+func reclaimDeletedOpenFilesIn(proc string, confirm utils.ConfirmFunc) error {
+	files := scanDeletedOpenFiles(proc)
+	var total int64
+	for _, f := range files {
+		total += f.size
+		fmt.Printf("  %10s  %s (held by %s)\n", utils.FormatBytes(uint64(f.size)), f.path, strings.Join(f.holders, ", "))
+	}
+
+	if total == 0 {
+		fmt.Println("No deleted files are holding disk space")
+		return nil
+	}
+	fmt.Printf("%d deleted file(s) held open, %s in total\n", len(files), utils.FormatBytes(uint64(total)))
+
+	if !confirm("Choose deleted files to truncate one at a time (truncating a file a process still uses can corrupt it)") {
+		fmt.Println("Skipping truncation of deleted files")
+		return nil
 	}
 
-	for _, command := range commands {
-		err := utils.Runner.RunFdOrFind(command.path, command.args, command.msg, true)
-		if err != nil {
-			fmt.Printf("Warning: %s: %v\n", command.msg, err)
+	for _, f := range files {
+		if f.size == 0 {
+			continue
+		}
+		if !confirm(fmt.Sprintf("Truncate %s (%s, held by %s)", f.path, utils.FormatBytes(uint64(f.size)), strings.Join(f.holders, ", "))) {
+			continue
+		}
+		if err := os.Truncate(f.fdPath, 0); err != nil {
+			fmt.Printf("Warning: Failed to truncate %s: %v\n", f.path, err)
+			continue
 		}
+		recordReclaimed(f.size)
 	}
 	return nil
 }
-
-func cleanJournalLogs() error {
-	return utils.Runner.RunWithIndicator("journalctl --vacuum-size=100M", "Limiting journal size to 100MB...")
-}
@@ -2,8 +2,12 @@ package cleaners
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // FakeEnvironment represents a fake system environment for testing
@@ -67,11 +71,7 @@ func TestClearApt(t *testing.T) {
 				t.Errorf("Unexpected command: %s", command)
 			}
 		case 2:
-			if command != "apt-get purge -y nano vim-tiny" {
-				t.Errorf("Unexpected command: %s", command)
-			}
-		case 3:
-			if command != "apt-get clean" {
+			if command != "dpkg --purge old-daemon:amd64 old-tool" {
 				t.Errorf("Unexpected command: %s", command)
 			}
 		default:
@@ -80,128 +80,235 @@ func TestClearApt(t *testing.T) {
 		return nil
 	}
 
-	err := clearApt()
+	dir := t.TempDir()
+	statusFile := filepath.Join(dir, "status")
+	if err := os.WriteFile(statusFile, []byte(testDpkgStatus), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archives := filepath.Join(dir, "archives")
+	writeTestFiles(t, archives, "nano_7.1-1_amd64.deb", "nano_7.2-1_amd64.deb", "removed_1.0_all.deb")
+
+	err := clearAptWith(statusFile, archives)
 	if err != nil {
 		t.Errorf("clearApt() error = %v, wantErr %v", err, false)
 	}
 
-	if callCount != 3 {
-		t.Errorf("Expected 3 calls to RunWithIndicator, got %d", callCount)
+	if callCount != 2 {
+		t.Errorf("Expected 2 calls to RunWithIndicator, got %d", callCount)
 	}
-}
 
-func TestRemoveOldLogs(t *testing.T) {
-	mock, _ := setupTestWithEnv()
+	assertFilesExist(t, archives, "nano_7.1-1_amd64.deb", "nano_7.2-1_amd64.deb")
+	assertFilesRemoved(t, archives, "removed_1.0_all.deb")
+}
 
-	callCount := 0
-	mock.RunWithIndicatorFunc = func(command, message string) error {
-		if command != "journalctl --vacuum-time=3d" {
-			t.Errorf("Unexpected command: %s", command)
-		}
-		callCount++
-		return nil
+func TestPurgeResidualConfigs(t *testing.T) {
+	statusFile := filepath.Join(t.TempDir(), "status")
+	if err := os.WriteFile(statusFile, []byte(testDpkgStatus), 0644); err != nil {
+		t.Fatal(err)
 	}
 
-	mock.RunFdOrFindFunc = func(path, args, message string, sudo bool) error {
-		if path != "/var/log" || !strings.Contains(args, "-type f -name \"*.log\" -mtime +30 -delete") {
-			t.Errorf("Unexpected RunFdOrFind call: path=%s, args=%s", path, args)
-		}
-		callCount++
-		return nil
+	mock := setupTest()
+	if err := purgeResidualConfigs(statusFile); err != nil {
+		t.Errorf("purgeResidualConfigs() error = %v", err)
+	}
+	expected := "dpkg --purge old-daemon:amd64 old-tool"
+	if len(mock.Commands) != 1 || mock.Commands[0] != expected {
+		t.Errorf("Expected command %q, got %v", expected, mock.Commands)
 	}
+}
+
+func TestRemoveOldLogs(t *testing.T) {
+	mock, _ := setupTestWithEnv()
+
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "log")
+	writeTestFiles(t, logDir, "auth.log", "auth.log.1", "auth.log.2.gz", "auth.log.3.gz", "old.log")
 
-	err := removeOldLogs()
+	err := removeOldLogsIn(logDir, filepath.Join(dir, "logrotate.conf"), filepath.Join(dir, "logrotate.d"))
 	if err != nil {
 		t.Errorf("removeOldLogs() error = %v, wantErr %v", err, false)
 	}
 
-	if callCount != 2 {
-		t.Errorf("Expected 2 calls (RunWithIndicator and RunFdOrFind), got %d", callCount)
+	assertFilesExist(t, logDir, "auth.log", "old.log")
+	if len(mock.Commands) != 0 {
+		t.Errorf("Expected no commands, got %v", mock.Commands)
 	}
 }
 
 func TestRemoveCrashReports(t *testing.T) {
-	mock, _ := setupTestWithEnv()
-
-	callCount := 0
-	mock.RunWithIndicatorFunc = func(command, message string) error {
-		if command != "rm -rf /var/crash/*" {
-			t.Errorf("Unexpected command: %s", command)
-		}
-		callCount++
-		return nil
+	crash := t.TempDir()
+	coredump := t.TempDir()
+	now := time.Now()
+	name := func(comm string, pid int, age time.Duration) string {
+		return fmt.Sprintf("core.%s.1000.0123456789abcdef0123456789abcdef.%d.%d.zst", comm, pid, now.Add(-age).UnixMicro())
 	}
-
-	mock.RunFdOrFindFunc = func(path, args, message string, sudo bool) error {
-		if path != "/var/lib/systemd/coredump" || args != "-type f -delete" {
-			t.Errorf("Unexpected RunFdOrFind call: path=%s, args=%s", path, args)
+	newest := name("app", 3, time.Hour)
+	older := name("app", 2, 2*time.Hour)
+	ancient := name("other", 1, 60*24*time.Hour)
+	busy := name("busy", 4, 2*time.Hour)
+	busyNewest := name("busy", 5, time.Hour)
+	writeTestFiles(t, coredump, newest, older, ancient, busy, busyNewest, "README")
+
+	writeApport := func(name, exe string, age time.Duration) {
+		path := filepath.Join(crash, name)
+		if err := os.WriteFile(path, []byte("ProblemType: Crash\nExecutablePath: "+exe+"\n"), 0640); err != nil {
+			t.Fatal(err)
+		}
+		when := now.Add(-age)
+		if err := os.Chtimes(path, when, when); err != nil {
+			t.Fatal(err)
 		}
-		callCount++
-		return nil
-	}
-
-	err := removeCrashReports()
-	if err != nil {
-		t.Errorf("removeCrashReports() error = %v, wantErr %v", err, false)
 	}
-
-	if callCount != 2 {
-		t.Errorf("Expected 2 calls (RunWithIndicator and RunFdOrFind), got %d", callCount)
+	writeApport("_usr_bin_app.1000.crash", "/usr/bin/app", time.Hour)
+	writeApport("_usr_bin_app.0.crash", "/usr/bin/app", 3*time.Hour)
+	writeTestFiles(t, crash, "_usr_bin_app.0.upload", "_usr_bin_app.1000.upload")
+
+	d := &deleter{open: map[string][]int{filepath.Join(coredump, busy): {4242}}}
+	removeCrashReportsIn(crash, coredump, 1, 30*24*time.Hour, d)
+
+	assertFilesExist(t, coredump, newest, busy, busyNewest, "README")
+	assertFilesRemoved(t, coredump, older, ancient)
+	assertFilesExist(t, crash, "_usr_bin_app.1000.crash", "_usr_bin_app.1000.upload")
+	assertFilesRemoved(t, crash, "_usr_bin_app.0.crash", "_usr_bin_app.0.upload")
+	if len(d.inUse) != 1 {
+		t.Errorf("Expected 1 file reported in use, got %v", d.inUse)
 	}
 }
 
 func TestRemoveTemp(t *testing.T) {
-	mock, _ := setupTestWithEnv()
-
-	expectedCalls := []struct {
-		path string
-		args string
-	}{
-		{"/tmp", "-type f -atime +10 -delete"},
-		{"/var/tmp", "-type f -atime +10 -delete"},
+	tmp := t.TempDir()
+	varTmp := t.TempDir()
+	writeTestFiles(t, tmp,
+		"old.txt", "fresh.txt", "nested/old.bin", "held.sock",
+		".X11-unix/X0", ".X0-lock", "keep-me/old.dat", "pinned.txt",
+		"systemd-private-0123456789abcdef0123456789abcdef-foo.service-AbC123/tmp/old",
+	)
+	writeTestFiles(t, varTmp, "old.dat", "recent.dat", "foo/old.dat")
+
+	rules := []tmpfilesRule{
+		{kind: 'q', path: varTmp, age: 30 * 24 * time.Hour},
+		{kind: 'd', path: filepath.Join(varTmp, "foo"), never: true},
+		{kind: 'x', path: filepath.Join(tmp, "keep-*")},
+		{kind: 'X', path: filepath.Join(tmp, "pinned.txt")},
 	}
 
-	callCount := 0
-	mock.RunFdOrFindFunc = func(path, args, message string, sudo bool) error {
-		if callCount >= len(expectedCalls) {
-			t.Errorf("Unexpected call to RunFdOrFind: path=%s, args=%s", path, args)
-			return nil
+	setAge := func(path string, age time.Duration) {
+		when := time.Now().Add(-age)
+		if err := os.Chtimes(path, when, when); err != nil {
+			t.Fatal(err)
 		}
-		expected := expectedCalls[callCount]
-		if path != expected.path || args != expected.args {
-			t.Errorf("Unexpected RunFdOrFind call: got path=%s, args=%s; want path=%s, args=%s", path, args, expected.path, expected.args)
-		}
-		callCount++
-		return nil
 	}
-
-	err := removeTemp()
-	if err != nil {
-		t.Errorf("removeTemp() error = %v, wantErr %v", err, false)
+	for _, name := range []string{
+		"old.txt", "nested/old.bin", "held.sock", ".X11-unix/X0", ".X0-lock", "keep-me/old.dat", "pinned.txt",
+		"systemd-private-0123456789abcdef0123456789abcdef-foo.service-AbC123/tmp/old",
+	} {
+		setAge(filepath.Join(tmp, name), 40*24*time.Hour)
 	}
-
-	if callCount != len(expectedCalls) {
-		t.Errorf("Expected %d calls to RunFdOrFind, got %d", len(expectedCalls), callCount)
+	setAge(filepath.Join(varTmp, "old.dat"), 40*24*time.Hour)
+	setAge(filepath.Join(varTmp, "foo/old.dat"), 40*24*time.Hour)
+	setAge(filepath.Join(varTmp, "recent.dat"), 20*24*time.Hour)
+
+	d := &deleter{open: map[string][]int{filepath.Join(tmp, "held.sock"): {7}}}
+	removeTempIn([]string{tmp, varTmp}, rules, tempMaxAge, d)
+
+	assertFilesRemoved(t, tmp, "old.txt", "nested/old.bin")
+	assertFilesRemoved(t, varTmp, "old.dat")
+	assertFilesExist(t, tmp, "fresh.txt", "nested", "held.sock", ".X11-unix/X0", ".X0-lock", "keep-me/old.dat", "pinned.txt",
+		"systemd-private-0123456789abcdef0123456789abcdef-foo.service-AbC123/tmp/old")
+	assertFilesExist(t, varTmp, "recent.dat", "foo/old.dat")
+	if len(d.inUse) != 1 {
+		t.Errorf("Expected 1 file reported in use, got %v", d.inUse)
 	}
 }
 
 func TestCleanJournalLogs(t *testing.T) {
-	mock, _ := setupTestWithEnv()
+	tests := []struct {
+		name     string
+		conf     string
+		options  Options
+		expected string
+	}{
+		{"Default", "", DefaultOptions(), "journalctl --vacuum-size=100M"},
+		{"JournaldConf", "[Journal]\nSystemMaxUse=500M\nMaxRetentionSec=1month\n", DefaultOptions(), "journalctl --vacuum-size=500M --vacuum-time=1month"},
+		{"Flags", "[Journal]\nSystemMaxUse=500M\n", Options{JournalVacuumFiles: 5}, "journalctl --vacuum-files=5"},
+	}
 
-	mock.RunWithIndicatorFunc = func(command, message string) error {
-		if command != "journalctl --vacuum-size=100M" {
-			t.Errorf("Unexpected command: %s", command)
-		}
-		return nil
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, _ := setupTestWithEnv()
+			var vacuum []string
+			mock.RunWithIndicatorFunc = func(command, message string) error {
+				vacuum = append(vacuum, command)
+				return nil
+			}
+
+			dir := t.TempDir()
+			confFile := filepath.Join(dir, "journald.conf")
+			if err := os.WriteFile(confFile, []byte(tt.conf), 0644); err != nil {
+				t.Fatal(err)
+			}
+			defer SetOptions(options)
+			SetOptions(tt.options)
+
+			err := cleanJournalLogsWith(confFile, nil, filepath.Join(dir, "journal"))
+			if err != nil {
+				t.Errorf("cleanJournalLogsWith() error = %v, wantErr %v", err, false)
+			}
+			if len(vacuum) != 1 || vacuum[0] != tt.expected {
+				t.Errorf("Expected %q, got %v", tt.expected, vacuum)
+			}
+		})
 	}
+}
 
-	err := cleanJournalLogs()
-	if err != nil {
-		t.Errorf("cleanJournalLogs() error = %v, wantErr %v", err, false)
+func TestReclaimDeletedOpenFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		answers   map[string]bool
+		expected  map[string]int64
+		reclaimed uint64
+	}{
+		{"Declined", map[string]bool{}, map[string]int64{"journal.tmp": 2048, "ibtmp1": 4096}, 0},
+		{"ChosenFile", map[string]bool{"Choose": true, "journal.tmp": true}, map[string]int64{"journal.tmp": 0, "ibtmp1": 4096}, 2048},
+		{"NoneChosen", map[string]bool{"Choose": true}, map[string]int64{"journal.tmp": 2048, "ibtmp1": 4096}, 0},
 	}
 
-	if len(mock.Commands) != 1 {
-		t.Errorf("Expected 1 command, got %d", len(mock.Commands))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			journal := filepath.Join(dir, "journal.tmp (deleted)")
+			if err := os.WriteFile(journal, make([]byte, 2048), 0644); err != nil {
+				t.Fatal(err)
+			}
+			ibtmp := filepath.Join(dir, "ibtmp1 (deleted)")
+			if err := os.WriteFile(ibtmp, make([]byte, 4096), 0644); err != nil {
+				t.Fatal(err)
+			}
+			proc := filepath.Join(dir, "proc")
+			writeFakeProc(t, proc, map[string][]string{"300": {journal}, "400": {ibtmp}})
+
+			confirm := func(label string) bool {
+				for key, answer := range tt.answers {
+					if strings.Contains(label, key) {
+						return answer
+					}
+				}
+				return false
+			}
+			reclaimedBytes.Store(0)
+			if err := reclaimDeletedOpenFilesIn(proc, confirm); err != nil {
+				t.Errorf("reclaimDeletedOpenFiles() error = %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				if size := fileSize(filepath.Join(dir, name+" (deleted)")); size != expected {
+					t.Errorf("Expected %s to have size %d after cleanup, got %d", name, expected, size)
+				}
+			}
+			if reclaimedBytes.Load() != tt.reclaimed {
+				t.Errorf("Expected %d reclaimed bytes to be recorded, got %d", tt.reclaimed, reclaimedBytes.Load())
+			}
+		})
 	}
 }
 
@@ -1,6 +1,8 @@
 package cleaners
 
 import (
+	"io"
+
 	"github.com/cosmix/broom/internal/utils"
 )
 
@@ -9,6 +11,7 @@ type MockUtilsRunner struct {
 	RunWithIndicatorFunc func(command, message string) error
 	RunFdOrFindFunc      func(path, args, message string, sudo bool) error
 	RunWithOutputFunc    func(command string) (string, error)
+	RunWithIOFunc        func(command string, stdin io.Reader, stdout io.Writer) error
 	CommandExistsFunc    func(command string) bool
 	Commands             []string
 }
@@ -29,6 +32,11 @@ func (m *MockUtilsRunner) RunWithOutput(command string) (string, error) {
 	return m.RunWithOutputFunc(command)
 }
 
+func (m *MockUtilsRunner) RunWithIO(command string, stdin io.Reader, stdout io.Writer) error {
+	m.Commands = append(m.Commands, command)
+	return m.RunWithIOFunc(command, stdin, stdout)
+}
+
 func (m *MockUtilsRunner) CommandExists(command string) bool {
 	return m.CommandExistsFunc(command)
 }
@@ -38,8 +46,18 @@ func setupTest() *MockUtilsRunner {
 		RunWithIndicatorFunc: func(command, message string) error { return nil },
 		RunFdOrFindFunc:      func(path, args, message string, sudo bool) error { return nil },
 		RunWithOutputFunc:    func(command string) (string, error) { return "", nil },
+		RunWithIOFunc:        copyStdin,
 		CommandExistsFunc:    func(command string) bool { return true },
 	}
 	utils.SetUtilsRunner(mock)
 	return mock
 }
+
+// copyStdin stands in for a command that passes its input through unchanged.
+func copyStdin(command string, stdin io.Reader, stdout io.Writer) error {
+	if stdin == nil {
+		return nil
+	}
+	_, err := io.Copy(stdout, stdin)
+	return err
+}
@@ -0,0 +1,124 @@
+package cleaners
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+const maxPNGTextChunk = 64 * 1024
+
+var thumbnailDirs = []string{".cache/thumbnails", ".thumbnails"}
+
+var removableMediaRoots = []string{"/media/", "/run/media/", "/mnt/"}
+
+func readPNGText(r io.ReadSeeker) (map[string]string, bool) {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return nil, false
+	}
+
+	text := make(map[string]string)
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return text, true
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		if kind == "IEND" {
+			return text, true
+		}
+		if (kind != "tEXt" && kind != "iTXt") || length > maxPNGTextChunk {
+			if _, err := r.Seek(length+4, io.SeekCurrent); err != nil {
+				return text, true
+			}
+			continue
+		}
+
+		data := make([]byte, length+4)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return text, true
+		}
+		key, value, ok := bytes.Cut(data[:length], []byte{0})
+		if !ok {
+			continue
+		}
+		if kind == "iTXt" {
+			// compression flag, compression method, language tag, translated keyword
+			if len(value) < 2 || value[0] != 0 {
+				continue
+			}
+			parts := bytes.SplitN(value[2:], []byte{0}, 3)
+			if len(parts) != 3 {
+				continue
+			}
+			value = parts[2]
+		}
+		text[string(key)] = string(value)
+	}
+}
+
+func readThumbnailInfo(path string) (uri string, mtime int64, hasMTime bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, false
+	}
+	defer f.Close()
+
+	text, ok := readPNGText(f)
+	if !ok {
+		return "", 0, false
+	}
+	if value, ok := text["Thumb::MTime"]; ok {
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			mtime, hasMTime = n, true
+		}
+	}
+	return text["Thumb::URI"], mtime, hasMTime
+}
+
+func thumbnailStale(uri string, mtime int64, hasMTime bool) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return false
+	}
+
+	info, err := os.Stat(u.Path)
+	if err != nil {
+		return localFileGone(uri)
+	}
+	return hasMTime && info.ModTime().Unix() != mtime
+}
+
+func pruneThumbnails(dir string, d *deleter) {
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".png") {
+			return nil
+		}
+		uri, mtime, hasMTime := readThumbnailInfo(path)
+		if uri != "" && thumbnailStale(uri, mtime, hasMTime) {
+			d.removeFile(path)
+		}
+		return nil
+	})
+}
+
+func cleanThumbnails() error {
+	d := newDeleter()
+	for _, home := range userHomes(passwdFile) {
+		for _, dir := range thumbnailDirs {
+			pruneThumbnails(filepath.Join(home.dir, dir), d)
+		}
+	}
+	d.report("stale thumbnail(s)")
+	return nil
+}
@@ -0,0 +1,97 @@
+package cleaners
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeThumbnail writes a 1x1 PNG carrying the given tEXt chunks.
+func writeThumbnail(t *testing.T, path string, text map[string]string) {
+	t.Helper()
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Text chunks go right after the 8-byte signature and the 25-byte IHDR chunk.
+	data := img.Bytes()
+	out := append([]byte(nil), data[:33]...)
+	for key, value := range text {
+		chunk := append([]byte("tEXt"+key+"\x00"), value...)
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(chunk)-4))
+		crc := make([]byte, 4)
+		binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(chunk))
+		out = append(out, length...)
+		out = append(out, chunk...)
+		out = append(out, crc...)
+	}
+	out = append(out, data[33:]...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, out, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadThumbnailInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "thumb.png")
+	writeThumbnail(t, path, map[string]string{"Thumb::URI": "file:///home/alice/a%20b.jpg", "Thumb::MTime": "1700000000", "Software": "GNOME::ThumbnailFactory"})
+
+	uri, mtime, hasMTime := readThumbnailInfo(path)
+	if uri != "file:///home/alice/a%20b.jpg" || mtime != 1700000000 || !hasMTime {
+		t.Errorf("Unexpected thumbnail info: %q %d %v", uri, mtime, hasMTime)
+	}
+
+	writeTestFiles(t, dir, "not-a-png.png")
+	if uri, _, _ := readThumbnailInfo(filepath.Join(dir, "not-a-png.png")); uri != "" {
+		t.Errorf("Expected no URI from a non-PNG file, got %q", uri)
+	}
+}
+
+func TestPruneThumbnails(t *testing.T) {
+	src := t.TempDir()
+	cache := t.TempDir()
+	writeTestFiles(t, src, "same.jpg", "changed.jpg", "with space.jpg")
+	mtimeOf := func(name string) string {
+		info, err := os.Stat(filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strconv.FormatInt(info.ModTime().Unix(), 10)
+	}
+	uriOf := func(name string) string {
+		return "file://" + filepath.ToSlash(filepath.Join(src, name))
+	}
+
+	writeThumbnail(t, filepath.Join(cache, "normal/same.png"), map[string]string{"Thumb::URI": uriOf("same.jpg"), "Thumb::MTime": mtimeOf("same.jpg")})
+	writeThumbnail(t, filepath.Join(cache, "normal/space.png"), map[string]string{"Thumb::URI": uriOf("with%20space.jpg"), "Thumb::MTime": mtimeOf("with space.jpg")})
+	writeThumbnail(t, filepath.Join(cache, "large/changed.png"), map[string]string{"Thumb::URI": uriOf("changed.jpg"), "Thumb::MTime": mtimeOf("changed.jpg")})
+	writeThumbnail(t, filepath.Join(cache, "large/gone.png"), map[string]string{"Thumb::URI": uriOf("gone.jpg")})
+	writeThumbnail(t, filepath.Join(cache, "fail/gnome/gone.png"), map[string]string{"Thumb::URI": uriOf("gone.jpg")})
+	writeThumbnail(t, filepath.Join(cache, "normal/remote.png"), map[string]string{"Thumb::URI": "sftp://host/gone.jpg"})
+	writeThumbnail(t, filepath.Join(cache, "normal/usb.png"), map[string]string{"Thumb::URI": "file:///media/nobody/UNPLUGGED-DRIVE/a.jpg"})
+	writeThumbnail(t, filepath.Join(cache, "normal/nometa.png"), nil)
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(src, "changed.jpg"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &deleter{}
+	pruneThumbnails(cache, d)
+
+	assertFilesRemoved(t, cache, "large/changed.png", "large/gone.png", "fail/gnome/gone.png")
+	assertFilesExist(t, cache, "normal/same.png", "normal/space.png", "normal/remote.png", "normal/usb.png", "normal/nometa.png")
+}
@@ -0,0 +1,221 @@
+package cleaners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+var tmpfilesDirs = []string{"/etc/tmpfiles.d", "/run/tmpfiles.d", "/usr/lib/tmpfiles.d"}
+
+type tmpfilesRule struct {
+	kind  byte
+	path  string
+	age   time.Duration
+	never bool
+}
+
+func parseTmpfilesConfig(r io.Reader) []tmpfilesRule {
+	var rules []tmpfilesRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.Contains(fields[1], "%") {
+			continue
+		}
+
+		// The type may be followed by modifiers, such as "!" for lines that
+		// only apply at boot.
+		rule := tmpfilesRule{kind: fields[0][0], path: filepath.Clean(fields[1])}
+		bootOnly := strings.Contains(fields[0][1:], "!")
+		switch rule.kind {
+		case 'x', 'X':
+		case 'd', 'D', 'e', 'v', 'q', 'Q', 'C':
+			if bootOnly {
+				continue
+			}
+			// A missing age is the same as "-". D directories are emptied
+			// at boot, so their contents are fair game without an age.
+			if len(fields) < 6 || fields[5] == "-" {
+				if rule.kind == 'D' {
+					continue
+				}
+				rule.never = true
+				break
+			}
+			age, ok := parseTmpfilesAge(fields[5])
+			if !ok {
+				continue
+			}
+			rule.age = age
+		default:
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+var tmpfilesAgeUnits = map[string]time.Duration{
+	"us": time.Microsecond, "usec": time.Microsecond,
+	"ms": time.Millisecond, "msec": time.Millisecond,
+	"": time.Second, "s": time.Second, "sec": time.Second,
+	"m": time.Minute, "min": time.Minute,
+	"h": time.Hour, "hr": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"M": 30*24*time.Hour + 10*time.Hour + 30*time.Minute,
+	"y": 365*24*time.Hour + 6*time.Hour,
+}
+
+func parseTmpfilesAge(s string) (time.Duration, bool) {
+	if s == "-" || s == "" || strings.HasPrefix(s, "~") {
+		return 0, false
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+		if i == 0 {
+			return 0, false
+		}
+		if i < 0 {
+			i = len(s)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, false
+		}
+		s = s[i:]
+		j := strings.IndexFunc(s, unicode.IsDigit)
+		if j < 0 {
+			j = len(s)
+		}
+		unit, ok := tmpfilesAgeUnits[s[:j]]
+		if !ok {
+			return 0, false
+		}
+		s = s[j:]
+		total += time.Duration(n) * unit
+	}
+	return total, total > 0
+}
+
+func loadTmpfilesRules(dirs []string) []tmpfilesRule {
+	var rules []tmpfilesRule
+	for _, path := range configFragments(dirs, ".conf") {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, parseTmpfilesConfig(f)...)
+		f.Close()
+	}
+	return rules
+}
+
+func tmpfilesExcluded(rules []tmpfilesRule, path string) (excluded, recursive bool) {
+	for _, rule := range rules {
+		if rule.kind != 'x' && rule.kind != 'X' {
+			continue
+		}
+		if matched, _ := filepath.Match(rule.path, path); matched {
+			if rule.kind == 'x' {
+				return true, true
+			}
+			excluded = true
+		}
+	}
+	return excluded, false
+}
+
+func tmpfilesAge(rules []tmpfilesRule, path string) (age time.Duration, never bool) {
+	depth := -1
+	for _, rule := range rules {
+		if rule.age == 0 && !rule.never {
+			continue
+		}
+		if path != rule.path && !strings.HasPrefix(path, rule.path+"/") {
+			continue
+		}
+		if len(rule.path) > depth {
+			age, never, depth = rule.age, rule.never, len(rule.path)
+		}
+	}
+	return age, never
+}
+
+var tempSocketDirs = map[string]bool{
+	".X11-unix": true, ".ICE-unix": true, ".XIM-unix": true, ".font-unix": true, ".Test-unix": true,
+}
+
+func isLockFile(name string) bool {
+	return strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, "-lock") || strings.HasSuffix(name, ".lck") || strings.HasSuffix(name, ".pid")
+}
+
+const privateTmpPrefix = "systemd-private-"
+
+// parsePrivateTmpDir splits systemd-private-<boot id>-<unit>-<random>.
+func parsePrivateTmpDir(name string) (bootID, unit string, ok bool) {
+	rest, found := strings.CutPrefix(name, privateTmpPrefix)
+	if !found || len(rest) < 34 || rest[32] != '-' {
+		return "", "", false
+	}
+	bootID, rest = rest[:32], rest[33:]
+	i := strings.LastIndexByte(rest, '-')
+	if i <= 0 {
+		return "", "", false
+	}
+	return bootID, rest[:i], true
+}
+
+func currentBootID(proc string) string {
+	data, err := os.ReadFile(filepath.Join(proc, "sys/kernel/random/boot_id"))
+	if err != nil {
+		return ""
+	}
+	return strings.ReplaceAll(strings.TrimSpace(string(data)), "-", "")
+}
+
+func activeSystemdUnits() map[string]bool {
+	output, err := utils.Runner.RunWithOutput("systemctl list-units --all --no-legend --plain --state=active,activating,deactivating,reloading")
+	if err != nil {
+		return nil
+	}
+	units := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			units[fields[0]] = true
+		}
+	}
+	return units
+}
+
+func removeStalePrivateTmp(dirs []string, bootID string, active map[string]bool, d *deleter) {
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			id, unit, ok := parsePrivateTmpDir(entry.Name())
+			if !ok || !entry.IsDir() {
+				continue
+			}
+			stale := bootID != "" && id != bootID
+			if !stale && active != nil && id == bootID {
+				stale = !active[unit]
+			}
+			if stale {
+				d.removeTree(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+}
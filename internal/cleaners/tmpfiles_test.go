@@ -0,0 +1,134 @@
+package cleaners
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTmpfilesConfig = `# See tmpfiles.d(5) for details
+D /tmp 1777 root root -
+q /var/tmp 1777 root root 30d
+d /var/cache/app 0755 app app 1w2d
+d /var/tmp/foo 0755 root root -
+v /var/tmp/bar 0755 root root
+D! /run/boot-only 0755 root root 1d
+x /tmp/systemd-private-%b-*
+x /tmp/keep-*
+X /var/tmp/pinned
+L /etc/mtab - - - - ../proc/self/mounts
+e /var/lib/app/spool - - - ~5d
+`
+
+func TestParseTmpfilesConfig(t *testing.T) {
+	rules := parseTmpfilesConfig(strings.NewReader(testTmpfilesConfig))
+
+	expected := []tmpfilesRule{
+		{kind: 'q', path: "/var/tmp", age: 30 * 24 * time.Hour},
+		{kind: 'd', path: "/var/cache/app", age: 9 * 24 * time.Hour},
+		{kind: 'd', path: "/var/tmp/foo", never: true},
+		{kind: 'v', path: "/var/tmp/bar", never: true},
+		{kind: 'x', path: "/tmp/keep-*"},
+		{kind: 'X', path: "/var/tmp/pinned"},
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %d: %+v", len(expected), len(rules), rules)
+	}
+	for i, rule := range rules {
+		if rule != expected[i] {
+			t.Errorf("Rule %d: got %+v, want %+v", i, rule, expected[i])
+		}
+	}
+}
+
+func TestParseTmpfilesAge(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		ok    bool
+	}{
+		{"10d", 10 * 24 * time.Hour, true},
+		{"1w2d", 9 * 24 * time.Hour, true},
+		{"3600", time.Hour, true},
+		{"12h30min", 12*time.Hour + 30*time.Minute, true},
+		{"-", 0, false},
+		{"~10d", 0, false},
+		{"10x", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseTmpfilesAge(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseTmpfilesAge(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLoadTmpfilesRulesMasking(t *testing.T) {
+	etc := t.TempDir()
+	lib := t.TempDir()
+	writeTestFile(t, filepath.Join(lib, "tmp.conf"), "q /var/tmp 1777 root root 30d\n")
+	writeTestFile(t, filepath.Join(etc, "tmp.conf"), "q /var/tmp 1777 root root 5d\n")
+	writeTestFile(t, filepath.Join(lib, "x11.conf"), "x /tmp/.X11-unix\n")
+
+	rules := loadTmpfilesRules([]string{etc, lib})
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %+v", rules)
+	}
+	if age, _ := tmpfilesAge(rules, "/var/tmp/foo/bar"); age != 5*24*time.Hour {
+		t.Errorf("Expected /etc to mask /usr/lib, got age %v", age)
+	}
+	if excluded, recursive := tmpfilesExcluded(rules, "/tmp/.X11-unix"); !excluded || !recursive {
+		t.Error("Expected /tmp/.X11-unix to be excluded recursively")
+	}
+}
+
+func TestTmpfilesAgeNever(t *testing.T) {
+	rules := parseTmpfilesConfig(strings.NewReader(testTmpfilesConfig))
+	if _, never := tmpfilesAge(rules, "/var/tmp/foo/data"); !never {
+		t.Error("Expected /var/tmp/foo not to be aged")
+	}
+	if age, never := tmpfilesAge(rules, "/var/tmp/other"); never || age != 30*24*time.Hour {
+		t.Errorf("Expected /var/tmp to be aged 30 days, got %v, %v", age, never)
+	}
+	// A deeper rule with an age overrides one that disables aging.
+	rules = append(rules, tmpfilesRule{kind: 'e', path: "/var/tmp/foo/cache", age: time.Hour})
+	if age, never := tmpfilesAge(rules, "/var/tmp/foo/cache/x"); never || age != time.Hour {
+		t.Errorf("Expected /var/tmp/foo/cache to be aged 1 hour, got %v, %v", age, never)
+	}
+}
+
+func TestParsePrivateTmpDir(t *testing.T) {
+	bootID, unit, ok := parsePrivateTmpDir("systemd-private-0123456789abcdef0123456789abcdef-systemd-resolved.service-Ab12Cd")
+	if !ok || bootID != "0123456789abcdef0123456789abcdef" || unit != "systemd-resolved.service" {
+		t.Errorf("Unexpected result: %q %q %v", bootID, unit, ok)
+	}
+	if _, _, ok := parsePrivateTmpDir("systemd-private-short-foo.service-x"); ok {
+		t.Error("Expected a malformed name to be rejected")
+	}
+}
+
+func TestRemoveStalePrivateTmp(t *testing.T) {
+	const current = "0123456789abcdef0123456789abcdef"
+	const previous = "fedcba9876543210fedcba9876543210"
+	tmp := t.TempDir()
+	writeTestFiles(t, tmp,
+		"systemd-private-"+current+"-running.service-AAAA/tmp/a",
+		"systemd-private-"+current+"-stopped.service-BBBB/tmp/b",
+		"systemd-private-"+previous+"-running.service-CCCC/tmp/c",
+		"unrelated/d",
+	)
+
+	d := &deleter{}
+	removeStalePrivateTmp([]string{tmp}, current, map[string]bool{"running.service": true}, d)
+
+	assertFilesExist(t, tmp, "systemd-private-"+current+"-running.service-AAAA", "unrelated/d")
+	assertFilesRemoved(t, tmp,
+		"systemd-private-"+current+"-stopped.service-BBBB",
+		"systemd-private-"+previous+"-running.service-CCCC",
+	)
+
+	writeTestFiles(t, tmp, "systemd-private-"+current+"-stopped.service-DDDD/tmp/b")
+	removeStalePrivateTmp([]string{tmp}, current, nil, d)
+	assertFilesExist(t, tmp, "systemd-private-"+current+"-stopped.service-DDDD")
+}
@@ -0,0 +1,174 @@
+package cleaners
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const trashInfoSuffix = ".trashinfo"
+
+var pseudoFilesystems = map[string]bool{
+	"proc": true, "sysfs": true, "devtmpfs": true, "devpts": true, "tmpfs": true, "cgroup": true, "cgroup2": true,
+	"securityfs": true, "pstore": true, "bpf": true, "tracefs": true, "debugfs": true, "mqueue": true,
+	"hugetlbfs": true, "configfs": true, "fusectl": true, "autofs": true, "binfmt_misc": true,
+	"efivarfs": true, "squashfs": true, "nsfs": true, "ramfs": true,
+}
+
+// findTrashDirs returns the home trash of each user and the .Trash/$uid and .Trash-$uid directories of each mount.
+func findTrashDirs(homes []userHome, mounts []mountEntry) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if info, err := os.Lstat(dir); err == nil && info.IsDir() && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		add(filepath.Join(dataHome, "Trash"))
+	}
+	for _, home := range homes {
+		add(filepath.Join(home.dir, ".local/share/Trash"))
+	}
+
+	for _, mount := range mounts {
+		if pseudoFilesystems[mount.fsType] {
+			continue
+		}
+		top := mount.mountPoint
+		entries, err := os.ReadDir(top)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if uid, ok := strings.CutPrefix(entry.Name(), ".Trash-"); ok && isNumeric(uid) {
+				add(filepath.Join(top, entry.Name()))
+			}
+		}
+		// The spec requires $top/.Trash to be a sticky directory, not a
+		// symlink, before its per-user subdirectories are trusted.
+		shared := filepath.Join(top, ".Trash")
+		info, err := os.Lstat(shared)
+		if err != nil || !info.IsDir() || info.Mode()&os.ModeSticky == 0 {
+			continue
+		}
+		users, _ := os.ReadDir(shared)
+		for _, entry := range users {
+			if isNumeric(entry.Name()) {
+				add(filepath.Join(shared, entry.Name()))
+			}
+		}
+	}
+	return dirs
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func parseTrashInfo(path string) (time.Time, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "DeletionDate=")
+		if !ok || section != "[Trash Info]" {
+			continue
+		}
+		when, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+		return when, err == nil
+	}
+	return time.Time{}, false
+}
+
+func cleanTrashDir(dir string, maxAge time.Duration, now time.Time, d *deleter) int {
+	infoDir := filepath.Join(dir, "info")
+	filesDir := filepath.Join(dir, "files")
+	entries, err := os.ReadDir(infoDir)
+	if err != nil {
+		return 0
+	}
+
+	emptied := 0
+	removedNames := make(map[string]bool)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), trashInfoSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		infoPath := filepath.Join(infoDir, entry.Name())
+		item := filepath.Join(filesDir, name)
+
+		_, err := os.Lstat(item)
+		orphaned := os.IsNotExist(err)
+		if !orphaned {
+			deleted, ok := parseTrashInfo(infoPath)
+			if !ok || now.Sub(deleted) < maxAge {
+				continue
+			}
+			if !d.removeTree(item) {
+				continue
+			}
+		}
+		if err := os.Remove(infoPath); err != nil {
+			fmt.Printf("Warning: Failed to remove %s: %v\n", infoPath, err)
+			continue
+		}
+		removedNames[name] = true
+		if !orphaned {
+			emptied++
+		}
+	}
+
+	if len(removedNames) > 0 {
+		pruneTrashDirectorySizes(filepath.Join(dir, "directorysizes"), removedNames)
+	}
+	return emptied
+}
+
+func pruneTrashDirectorySizes(path string, removed map[string]bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var kept []string
+	changed := false
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) == 3 {
+			if name, err := url.PathUnescape(fields[2]); err == nil && removed[name] {
+				changed = true
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	if !changed {
+		return
+	}
+	content := strings.Join(kept, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		fmt.Printf("Warning: Failed to update %s: %v\n", path, err)
+	}
+}
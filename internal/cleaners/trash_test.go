@@ -0,0 +1,107 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTrashItem trashes a file (or, when name has no extension, a directory
+// holding one file) into trashDir with the given deletion date.
+func writeTrashItem(t *testing.T, trashDir, name string, deleted time.Time) {
+	t.Helper()
+	if filepath.Ext(name) == "" {
+		writeTestFiles(t, filepath.Join(trashDir, "files"), filepath.Join(name, "inner.jpg"))
+	} else {
+		writeTestFiles(t, filepath.Join(trashDir, "files"), name)
+	}
+	info := "[Trash Info]\nPath=/home/alice/" + name + "\nDeletionDate=" + deleted.Format("2006-01-02T15:04:05") + "\n"
+	if err := os.MkdirAll(filepath.Join(trashDir, "info"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(trashDir, "info", name+trashInfoSuffix), []byte(info), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindTrashDirs(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "")
+	root := t.TempDir()
+	alice := filepath.Join(root, "home/alice")
+	bob := filepath.Join(root, "home/bob")
+	usb := filepath.Join(root, "media/usb")
+	shared := filepath.Join(root, "mnt/shared")
+	proc := filepath.Join(root, "proc")
+	writeTestFiles(t, alice, ".local/share/Trash/info/a.trashinfo")
+	writeTestFiles(t, bob, ".bashrc")
+	writeTestFiles(t, usb, ".Trash-1000/info/b.trashinfo", ".Trash-bogus/x", "data.bin")
+	writeTestFiles(t, shared, ".Trash/1001/info/c.trashinfo")
+	writeTestFiles(t, proc, ".Trash-0/info/d.trashinfo")
+
+	homes := []userHome{{name: "alice", uid: 1000, dir: alice}, {name: "bob", uid: 1001, dir: bob}}
+	mounts := []mountEntry{
+		{device: "/dev/sdb1", mountPoint: usb, fsType: "vfat"},
+		{device: "/dev/sdc1", mountPoint: shared, fsType: "ext4"},
+		{device: "proc", mountPoint: proc, fsType: "proc"},
+	}
+
+	dirs := findTrashDirs(homes, mounts)
+	expected := []string{filepath.Join(alice, ".local/share/Trash"), filepath.Join(usb, ".Trash-1000")}
+	if len(dirs) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, dirs)
+	}
+	for i := range expected {
+		if dirs[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, dirs)
+		}
+	}
+
+	// .Trash/$uid is only used when .Trash is sticky.
+	if err := os.Chmod(filepath.Join(shared, ".Trash"), 0777|os.ModeSticky); err != nil {
+		t.Fatal(err)
+	}
+	dirs = findTrashDirs(homes, mounts)
+	if len(dirs) != 3 || dirs[2] != filepath.Join(shared, ".Trash/1001") {
+		t.Errorf("Expected the sticky .Trash/1001 to be found, got %v", dirs)
+	}
+}
+
+func TestCleanTrashDir(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeTrashItem(t, dir, "old report.pdf", now.Add(-10*24*time.Hour))
+	writeTrashItem(t, dir, "olddir", now.Add(-10*24*time.Hour))
+	writeTrashItem(t, dir, "busy.db", now.Add(-10*24*time.Hour))
+	writeTrashItem(t, dir, "recent.txt", now.Add(-24*time.Hour))
+	writeTestFiles(t, filepath.Join(dir, "info"), "orphan.txt"+trashInfoSuffix, "notes.txt")
+	sizes := "4096 1700000000 olddir\n8192 1700000000 keptdir\n"
+	if err := os.WriteFile(filepath.Join(dir, "directorysizes"), []byte(sizes), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &deleter{open: map[string][]int{filepath.Join(dir, "files/busy.db"): {77}}}
+	emptied := cleanTrashDir(dir, 7*24*time.Hour, now, d)
+
+	if emptied != 2 {
+		t.Errorf("Expected 2 items emptied, got %d", emptied)
+	}
+	assertFilesRemoved(t, dir,
+		"files/old report.pdf", "info/old report.pdf.trashinfo",
+		"files/olddir", "info/olddir.trashinfo",
+		"info/orphan.txt.trashinfo",
+	)
+	assertFilesExist(t, dir,
+		"files/busy.db", "info/busy.db.trashinfo",
+		"files/recent.txt", "info/recent.txt.trashinfo",
+		"info/notes.txt",
+	)
+
+	data, err := os.ReadFile(filepath.Join(dir, "directorysizes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "8192 1700000000 keptdir\n" {
+		t.Errorf("Unexpected directorysizes: %q", data)
+	}
+}
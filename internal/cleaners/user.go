@@ -1,14 +1,32 @@
 package cleaners
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/cosmix/broom/internal/utils"
 )
 
+const (
+	homeDir      = "/home"
+	largeLogSize = 10 * 1024 * 1024
+	// Amount of an active log kept in the rotated copy when truncating it.
+	truncateTailSize = 1024 * 1024
+)
+
 func init() {
 	registerCleanup("home", Cleaner{CleanupFunc: cleanHomeDirectory, RequiresConfirmation: true})
 	registerCleanup("cache", Cleaner{CleanupFunc: cleanUserCaches, RequiresConfirmation: true})
+	registerCleanup("thumbnails", Cleaner{CleanupFunc: cleanThumbnails, RequiresConfirmation: false})
 	registerCleanup("trash", Cleaner{CleanupFunc: cleanUserTrash, RequiresConfirmation: true})
 	registerCleanup("user_logs", Cleaner{CleanupFunc: cleanUserHomeLogs, RequiresConfirmation: true})
 }
@@ -18,29 +36,198 @@ func cleanHomeDirectory() error {
 	if err != nil {
 		fmt.Printf("Warning: Error while removing temporary files in home directory: %v\n", err)
 	}
-	return utils.Runner.RunWithIndicator("rm -rf /home/*/.cache/thumbnails/*", "Clearing thumbnail cache...")
+	return nil
 }
 
 func cleanUserCaches() error {
-	err := utils.Runner.RunFdOrFind("/home", "-type d -name '.cache' -exec rm -rf {}/* \\;", "Clearing user caches...", true)
+	include, err := compileNamePatterns(options.CacheInclude)
 	if err != nil {
-		fmt.Printf("Warning: Error while clearing user caches: %v\n", err)
+		return err
+	}
+	exclude, err := compileNamePatterns(options.CacheExclude)
+	if err != nil {
+		return err
+	}
+
+	d := newDeleter()
+	maxAge := time.Duration(options.CacheMaxAgeDays) * 24 * time.Hour
+	for _, dir := range userCacheDirs(userHomes(passwdFile)) {
+		printCacheUsage(dir, cleanCacheDir(dir, maxAge, include, exclude, time.Now(), d))
 	}
+	d.report("unused cached file(s) from user caches")
 	return nil
 }
 
 func cleanUserTrash() error {
-	err := utils.Runner.RunFdOrFind("/home", "-type d -name 'Trash' -exec rm -rf {}/* \\;", "Emptying user trash folders...", true)
-	if err != nil {
-		fmt.Printf("Warning: Error while emptying user trash folders: %v\n", err)
+	var mounts []mountEntry
+	if f, err := os.Open(mountsFile); err == nil {
+		mounts = parseMounts(f)
+		f.Close()
+	}
+	d := newDeleter()
+	maxAge := time.Duration(options.TrashMaxAgeDays) * 24 * time.Hour
+	cleanUserTrashIn(findTrashDirs(userHomes(passwdFile), mounts), maxAge, d)
+	d.report("file(s) from trash")
+	return nil
+}
+
+func cleanUserTrashIn(dirs []string, maxAge time.Duration, d *deleter) {
+	now := time.Now()
+	for _, dir := range dirs {
+		if n := cleanTrashDir(dir, maxAge, now, d); n > 0 {
+			fmt.Printf("Emptied %d item(s) from %s\n", n, dir)
+		}
 	}
-	return utils.Runner.RunWithIndicator("rm -rf /root/.local/share/Trash/*", "Emptying trash for root...")
 }
 
 func cleanUserHomeLogs() error {
-	err := utils.Runner.RunFdOrFind("/home", "-type f -name '*.log' -size +10M -delete", "Removing large log files in user home directories...", true)
+	cleanLargeUserLogs(homeDir, options.LogAction, preferredCompression(utils.CommandExists), openFiles())
+	return nil
+}
+
+// cleanLargeUserLogs truncates logs held open instead, as deleting them frees nothing.
+func cleanLargeUserLogs(root, action, suffix string, open map[string][]int) {
+	count := 0
+	var saved int64
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), ".log") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() <= largeLogSize {
+			return nil
+		}
+
+		if pids, ok := open[path]; ok {
+			n, err := truncateActiveLog(path, truncateTailSize)
+			if err != nil {
+				fmt.Printf("Warning: Failed to truncate %s: %v\n", path, err)
+				return nil
+			}
+			fmt.Printf("Truncated %s (held open by pid %d)\n", path, pids[0])
+			saved += n
+		} else if action == logActionCompress {
+			n, err := compressFile(path, suffix)
+			if err != nil {
+				fmt.Printf("Warning: Failed to compress %s: %v\n", path, err)
+				return nil
+			}
+			saved += n
+		} else {
+			if err := os.Remove(path); err != nil {
+				fmt.Printf("Warning: Failed to remove %s: %v\n", path, err)
+				return nil
+			}
+			saved += info.Size()
+		}
+		count++
+		return nil
+	})
+
+	recordReclaimed(saved)
+	verb := "Removed"
+	if action == logActionCompress {
+		verb = "Compressed"
+	}
+	fmt.Printf("%s %d large log file(s) in user home directories, %s saved\n", verb, count, utils.FormatBytes(uint64(saved)))
+}
+
+// truncateActiveLog keeps the tail of path in path.1 and truncates path in place.
+// Lines written between the last copy and the truncation are lost.
+func truncateActiveLog(path string, tailSize int64) (int64, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		fmt.Printf("Warning: Error while removing large log files in user home directories: %v\n", err)
+		return 0, err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	start := max(info.Size()-tailSize, 0)
+	if start > 0 {
+		tail := make([]byte, info.Size()-start)
+		if _, err := src.ReadAt(tail, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+			start += int64(i) + 1
+		}
+	}
+
+	if err := shiftLogGenerations(path); err != nil {
+		return 0, err
+	}
+	rotated := path + ".1"
+	dst, err := os.OpenFile(rotated, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return 0, err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Lchown(rotated, int(stat.Uid), int(stat.Gid))
+	}
+
+	// Copy up to the current end of the log, then whatever was appended in
+	// the meantime.
+	if _, err := src.Seek(start, io.SeekStart); err != nil {
+		dst.Close()
+		return 0, err
+	}
+	for range 2 {
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return 0, err
+		}
+	}
+	if err := dst.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		return 0, err
+	}
+	return start, nil
+}
+
+// shiftLogGenerations renames path.N to path.N+1, oldest first.
+func shiftLogGenerations(path string) error {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	type generation struct {
+		n      int
+		suffix string
+	}
+	var generations []generation
+	prefix := filepath.Base(path) + "."
+	for _, entry := range entries {
+		rest, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok {
+			continue
+		}
+		suffix := ""
+		for _, s := range compressedLogSuffixes {
+			if trimmed, found := strings.CutSuffix(rest, s); found {
+				rest, suffix = trimmed, s
+				break
+			}
+		}
+		if n, err := strconv.Atoi(rest); err == nil && n > 0 && strconv.Itoa(n) == rest {
+			generations = append(generations, generation{n, suffix})
+		}
+	}
+
+	sort.Slice(generations, func(i, j int) bool { return generations[i].n > generations[j].n })
+	for _, g := range generations {
+		from := fmt.Sprintf("%s.%d%s", path, g.n, g.suffix)
+		to := fmt.Sprintf("%s.%d%s", path, g.n+1, g.suffix)
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
 	}
 	return nil
 }
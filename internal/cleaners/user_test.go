@@ -1,21 +1,24 @@
 package cleaners
 
 import (
+	"bytes"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCleanHomeDirectory(t *testing.T) {
 	tests := []struct {
 		name          string
 		fdOrFindErr   error
-		withIndErr    error
 		expectErr     bool
 		expectedCalls int
 	}{
-		{"Success", nil, nil, false, 2},
-		{"FdOrFindError", errors.New("fd error"), nil, false, 2},
-		{"RunWithIndError", nil, errors.New("run error"), true, 2},
+		{"Success", nil, false, 1},
+		{"FdOrFindError", errors.New("fd error"), false, 1},
 	}
 
 	for _, tt := range tests {
@@ -26,11 +29,6 @@ func TestCleanHomeDirectory(t *testing.T) {
 					return tt.fdOrFindErr
 				}
 			}
-			if tt.withIndErr != nil {
-				mock.RunWithIndicatorFunc = func(command, message string) error {
-					return tt.withIndErr
-				}
-			}
 
 			err := cleanHomeDirectory()
 
@@ -47,148 +45,189 @@ func TestCleanHomeDirectory(t *testing.T) {
 				if mock.Commands[0] != expectedFdCmd {
 					t.Errorf("Unexpected fd command: got %s, want %s", mock.Commands[0], expectedFdCmd)
 				}
-
-				if len(mock.Commands) > 1 {
-					expectedRmCmd := "rm -rf /home/*/.cache/thumbnails/*"
-					if mock.Commands[1] != expectedRmCmd {
-						t.Errorf("Unexpected rm command: got %s, want %s", mock.Commands[1], expectedRmCmd)
-					}
-				}
 			}
 		})
 	}
 }
 
 func TestCleanUserCaches(t *testing.T) {
-	tests := []struct {
-		name          string
-		fdOrFindErr   error
-		expectErr     bool
-		expectedCalls int
-	}{
-		{"Success", nil, false, 1},
-		{"Error", errors.New("fd error"), false, 1},
+	cache := t.TempDir()
+	writeTestFiles(t, cache,
+		"pip/wheels/old.whl",
+		"pip/wheels/fresh.whl",
+		"huggingface/hub/model.bin",
+		"chromium/Default/Cache/data_0",
+		"chromium/Default/Cache/data_1",
+		"fontconfig/cache-1",
+		"stray-file",
+	)
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour)
+	for _, name := range []string{"pip/wheels/old.whl", "huggingface/hub/model.bin", "chromium/Default/Cache/data_0", "chromium/Default/Cache/data_1", "fontconfig/cache-1", "stray-file"} {
+		if err := os.Chtimes(filepath.Join(cache, name), old, old); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := setupTest()
-			if tt.fdOrFindErr != nil {
-				mock.RunFdOrFindFunc = func(path, args, message string, sudo bool) error {
-					return tt.fdOrFindErr
-				}
-			}
-
-			err := cleanUserCaches()
-
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanUserCaches() error = %v, expectErr %v", err, tt.expectErr)
-			}
+	exclude, err := compileNamePatterns([]string{"hugging*", "fontconfig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inUse := filepath.Join(cache, "chromium/Default/Cache/data_0")
+	d := &deleter{open: map[string][]int{inUse: {1234}}}
+	usage := cleanCacheDir(cache, 30*24*time.Hour, nil, exclude, now, d)
+
+	assertFilesRemoved(t, cache, "pip/wheels/old.whl", "chromium/Default/Cache/data_1", "stray-file")
+	assertFilesExist(t, cache, "pip/wheels/fresh.whl", "huggingface/hub/model.bin", "fontconfig/cache-1", "chromium/Default/Cache/data_0")
+	if len(d.inUse) != 1 || !strings.Contains(d.inUse[0], "pid 1234") {
+		t.Errorf("Expected the in-use cache file to be reported, got %v", d.inUse)
+	}
 
-			if len(mock.Commands) != tt.expectedCalls {
-				t.Errorf("Expected %d call(s), got %d", tt.expectedCalls, len(mock.Commands))
-			}
+	byName := make(map[string]cacheUsage)
+	for _, u := range usage {
+		byName[u.name] = u
+	}
+	if len(usage) != 5 || !byName["huggingface"].excluded || byName["pip"].excluded {
+		t.Errorf("Unexpected usage breakdown: %+v", usage)
+	}
+	if byName["pip"].size != int64(len("pip/wheels/old.whl")+len("pip/wheels/fresh.whl")) || byName["pip"].freed != int64(len("pip/wheels/old.whl")) {
+		t.Errorf("Unexpected pip usage: %+v", byName["pip"])
+	}
 
-			if len(mock.Commands) > 0 {
-				expectedCmd := "fd/find /home -type d -name '.cache' -exec rm -rf {}/* \\;"
-				if mock.Commands[0] != expectedCmd {
-					t.Errorf("Unexpected command: got %s, want %s", mock.Commands[0], expectedCmd)
-				}
-			}
-		})
+	include, err := compileNamePatterns([]string{"chromium"})
+	if err != nil {
+		t.Fatal(err)
 	}
+	d = &deleter{}
+	cleanCacheDir(cache, 0, include, nil, now, d)
+	assertFilesRemoved(t, cache, "chromium/Default/Cache/data_0")
+	assertFilesExist(t, cache, "pip/wheels/fresh.whl", "fontconfig/cache-1")
 }
 
 func TestCleanUserTrash(t *testing.T) {
+	home := t.TempDir()
+	usb := t.TempDir()
+	now := time.Now()
+	writeTrashItem(t, filepath.Join(home, "Trash"), "old.txt", now.Add(-40*24*time.Hour))
+	writeTrashItem(t, filepath.Join(home, "Trash"), "new.txt", now.Add(-time.Hour))
+	writeTrashItem(t, filepath.Join(usb, ".Trash-1000"), "photos", now.Add(-90*24*time.Hour))
+
+	d := &deleter{}
+	cleanUserTrashIn([]string{filepath.Join(home, "Trash"), filepath.Join(usb, ".Trash-1000")}, 30*24*time.Hour, d)
+
+	assertFilesRemoved(t, home, "Trash/files/old.txt", "Trash/info/old.txt.trashinfo")
+	assertFilesExist(t, home, "Trash/files/new.txt", "Trash/info/new.txt.trashinfo")
+	assertFilesRemoved(t, usb, ".Trash-1000/files/photos", ".Trash-1000/info/photos.trashinfo")
+	if d.removed != 2 {
+		t.Errorf("Expected 2 trashed files removed, got %d", d.removed)
+	}
+}
+
+func TestCleanLargeUserLogs(t *testing.T) {
 	tests := []struct {
-		name          string
-		fdOrFindErr   error
-		withIndErr    error
-		expectErr     bool
-		expectedCalls int
+		name    string
+		action  string
+		exist   []string
+		removed []string
 	}{
-		{"Success", nil, nil, false, 2},
-		{"FdOrFindError", errors.New("fd error"), nil, false, 2},
-		{"RunWithIndError", nil, errors.New("run error"), true, 2},
+		{"Delete", logActionDelete, []string{"alice/small.log", "alice/big.txt"}, []string{"alice/app/big.log"}},
+		{"Compress", logActionCompress, []string{"alice/small.log", "alice/big.txt", "alice/app/big.log.gz"}, []string{"alice/app/big.log"}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := setupTest()
-			if tt.fdOrFindErr != nil {
-				mock.RunFdOrFindFunc = func(path, args, message string, sudo bool) error {
-					return tt.fdOrFindErr
+			root := t.TempDir()
+			writeTestFiles(t, root, "alice/small.log")
+			big := make([]byte, largeLogSize+1)
+			for _, name := range []string{"alice/app/big.log", "alice/big.txt"} {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
 				}
-			}
-			if tt.withIndErr != nil {
-				mock.RunWithIndicatorFunc = func(command, message string) error {
-					return tt.withIndErr
+				if err := os.WriteFile(path, big, 0644); err != nil {
+					t.Fatal(err)
 				}
 			}
 
-			err := cleanUserTrash()
+			reclaimedBytes.Store(0)
+			cleanLargeUserLogs(root, tt.action, ".gz", nil)
 
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanUserTrash() error = %v, expectErr %v", err, tt.expectErr)
-			}
-
-			if len(mock.Commands) != tt.expectedCalls {
-				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, len(mock.Commands))
-			}
-
-			if len(mock.Commands) > 0 {
-				expectedFdCmd := "fd/find /home -type d -name 'Trash' -exec rm -rf {}/* \\;"
-				if mock.Commands[0] != expectedFdCmd {
-					t.Errorf("Unexpected fd command: got %s, want %s", mock.Commands[0], expectedFdCmd)
-				}
-
-				if len(mock.Commands) > 1 {
-					expectedRmCmd := "rm -rf /root/.local/share/Trash/*"
-					if mock.Commands[1] != expectedRmCmd {
-						t.Errorf("Unexpected rm command: got %s, want %s", mock.Commands[1], expectedRmCmd)
-					}
-				}
+			assertFilesExist(t, root, tt.exist...)
+			assertFilesRemoved(t, root, tt.removed...)
+			if reclaimedBytes.Load() == 0 {
+				t.Error("Expected the saved bytes to be recorded")
 			}
 		})
 	}
 }
 
-func TestCleanUserHomeLogs(t *testing.T) {
-	tests := []struct {
-		name          string
-		fdOrFindErr   error
-		expectErr     bool
-		expectedCalls int
-	}{
-		{"Success", nil, false, 1},
-		{"Error", errors.New("fd error"), false, 1},
+func TestCleanLargeUserLogsActiveLog(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "alice/daemon.log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	line := []byte(strings.Repeat("x", 99) + "\n")
+	content := bytes.Repeat(line, largeLogSize/len(line)+10)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mock := setupTest()
-			if tt.fdOrFindErr != nil {
-				mock.RunFdOrFindFunc = func(path, args, message string, sudo bool) error {
-					return tt.fdOrFindErr
-				}
-			}
+	cleanLargeUserLogs(root, logActionDelete, ".gz", map[string][]int{path: {1234}})
 
-			err := cleanUserHomeLogs()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected active log to be kept: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected active log to be truncated, size is %d", info.Size())
+	}
 
-			if (err != nil) != tt.expectErr {
-				t.Errorf("cleanUserHomeLogs() error = %v, expectErr %v", err, tt.expectErr)
-			}
+	tail, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("Expected the tail to be copied to a rotated file: %v", err)
+	}
+	if int64(len(tail)) > truncateTailSize || len(tail)%len(line) != 0 || !bytes.HasSuffix(content, tail) {
+		t.Errorf("Unexpected tail of %d bytes", len(tail))
+	}
+}
 
-			if len(mock.Commands) != tt.expectedCalls {
-				t.Errorf("Expected %d call(s), got %d", tt.expectedCalls, len(mock.Commands))
-			}
+func TestTruncateActiveLogShiftsGenerations(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"app.log":      "line one\nline two\n",
+		"app.log.1":    "first generation",
+		"app.log.2.gz": "second generation",
+		"app.log.bak":  "backup",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-			if len(mock.Commands) > 0 {
-				expectedCmd := "fd/find /home -type f -name '*.log' -size +10M -delete"
-				if mock.Commands[0] != expectedCmd {
-					t.Errorf("Unexpected command: got %s, want %s", mock.Commands[0], expectedCmd)
-				}
-			}
-		})
+	freed, err := truncateActiveLog(filepath.Join(dir, "app.log"), 12)
+	if err != nil {
+		t.Fatalf("truncateActiveLog() error = %v", err)
+	}
+	if freed != int64(len("line one\n")) {
+		t.Errorf("Expected the first line to be freed, got %d bytes", freed)
+	}
+
+	for name, expected := range map[string]string{
+		"app.log":      "",
+		"app.log.1":    "line two\n",
+		"app.log.2":    "first generation",
+		"app.log.3.gz": "second generation",
+		"app.log.bak":  "backup",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Expected %s: %v", name, err)
+			continue
+		}
+		if string(data) != expected {
+			t.Errorf("Expected %s to hold %q, got %q", name, expected, data)
+		}
 	}
+	assertFilesRemoved(t, dir, "app.log.2.gz")
 }
@@ -0,0 +1,95 @@
+package cleaners
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+const cacheUsageRows = 20
+
+type cacheUsage struct {
+	name     string
+	size     int64
+	freed    int64
+	excluded bool
+}
+
+// userCacheDirs returns $XDG_CACHE_HOME, when set, and the ~/.cache directory of each user.
+func userCacheDirs(homes []userHome) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		add(cacheHome)
+	}
+	for _, home := range homes {
+		add(filepath.Join(home.dir, ".cache"))
+	}
+	return dirs
+}
+
+func cleanCacheDir(dir string, maxAge time.Duration, include, exclude []nameMatcher, now time.Time, d *deleter) []cacheUsage {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	usage := make([]cacheUsage, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		u := cacheUsage{name: name, size: treeSize(path)}
+		if (len(include) > 0 && !matchesAny(include, name)) || matchesAny(exclude, name) {
+			u.excluded = true
+			usage = append(usage, u)
+			continue
+		}
+
+		before := d.freed
+		filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil || now.Sub(lastUsed(info)) < maxAge {
+				return nil
+			}
+			d.removeFile(file)
+			return nil
+		})
+		u.freed = d.freed - before
+		usage = append(usage, u)
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].size > usage[j].size })
+	return usage
+}
+
+func printCacheUsage(dir string, usage []cacheUsage) {
+	if len(usage) == 0 {
+		return
+	}
+	fmt.Printf("Cache usage in %s:\n", dir)
+	for i, u := range usage {
+		if i == cacheUsageRows {
+			fmt.Printf("  ... and %d more\n", len(usage)-i)
+			break
+		}
+		status := "freed " + utils.FormatBytes(uint64(u.freed))
+		if u.excluded {
+			status = "excluded"
+		}
+		fmt.Printf("  %-32s %10s  %s\n", u.name, utils.FormatBytes(uint64(u.size)), status)
+	}
+}
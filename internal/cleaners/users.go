@@ -0,0 +1,59 @@
+package cleaners
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const passwdFile = "/etc/passwd"
+
+type userHome struct {
+	name string
+	uid  int
+	gid  int
+	dir  string
+}
+
+// userHomes returns root and the users with uid 1000 and above, except nobody.
+func userHomes(passwdFile string) []userHome {
+	f, err := os.Open(passwdFile)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var homes []userHome
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 7 {
+			continue
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil || (uid != 0 && (uid < 1000 || uid == 65534)) {
+			continue
+		}
+		gid, _ := strconv.Atoi(fields[3])
+		dir := fields[5]
+		if seen[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[dir] = true
+		homes = append(homes, userHome{name: fields[0], uid: uid, gid: gid, dir: dir})
+	}
+	return homes
+}
+
+func userNames(homes []userHome) map[int]string {
+	names := make(map[int]string)
+	for _, home := range homes {
+		names[home.uid] = home.name
+	}
+	return names
+}
@@ -0,0 +1,28 @@
+package cleaners
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUserHomes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "root/.profile", "home/alice/.profile", "home/bob/.profile")
+	passwd := "root:x:0:0:root:" + filepath.Join(dir, "root") + ":/bin/bash\n" +
+		"daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin\n" +
+		"alice:x:1000:1000:Alice:" + filepath.Join(dir, "home/alice") + ":/bin/bash\n" +
+		"bob:x:1001:1001::" + filepath.Join(dir, "home/bob") + ":/bin/zsh\n" +
+		"gone:x:1002:1002::" + filepath.Join(dir, "home/gone") + ":/bin/sh\n" +
+		"nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin\n" +
+		"broken line\n"
+	passwdFile := filepath.Join(dir, "passwd")
+	writeTestFile(t, passwdFile, passwd)
+
+	homes := userHomes(passwdFile)
+	if len(homes) != 3 {
+		t.Fatalf("Expected 3 homes, got %+v", homes)
+	}
+	if homes[0].name != "root" || homes[1].name != "alice" || homes[1].uid != 1000 || homes[2].dir != filepath.Join(dir, "home/bob") {
+		t.Errorf("Unexpected homes: %+v", homes)
+	}
+}
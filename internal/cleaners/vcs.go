@@ -0,0 +1,193 @@
+package cleaners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+type vcsStatus int
+
+const (
+	vcsNone      vcsStatus = iota // not in a working tree
+	vcsIgnored                    // ignored by the VCS
+	vcsUntracked                  // untracked but not ignored
+	vcsTracked                    // tracked, or a directory holding tracked files
+	vcsUnknown                    // the VCS could not be queried
+)
+
+type vcsRepo struct {
+	root      string
+	kind      string
+	failed    bool
+	tracked   map[string]bool
+	untracked map[string]bool
+	ignored   map[string]bool
+}
+
+type vcsIndex struct {
+	repos  map[string]*vcsRepo
+	owners map[int]string
+}
+
+func newVCSIndex(owners map[int]string) *vcsIndex {
+	return &vcsIndex{repos: make(map[string]*vcsRepo), owners: owners}
+}
+
+var vcsCommands = map[string][3]string{
+	"git": {
+		"git -C %s ls-files -z",
+		"git -C %s ls-files -z --others --exclude-standard",
+		"git -C %s ls-files -z --others --ignored --exclude-standard --directory",
+	},
+	"hg": {
+		"hg --cwd %s status -mardc -n -0",
+		"hg --cwd %s status -u -n -0",
+		"hg --cwd %s status -i -n -0",
+	},
+}
+
+func findVCSRoot(path string) (string, string) {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, "git"
+		}
+		if info, err := os.Lstat(filepath.Join(dir, ".hg")); err == nil && info.IsDir() {
+			return dir, "hg"
+		}
+		if dir == filepath.Dir(dir) {
+			return "", ""
+		}
+	}
+}
+
+// runAsOwner returns command run with runuser as the user owning uid, or as is for root.
+func runAsOwner(uid int, owners map[int]string, command string) (string, bool) {
+	if uid == 0 {
+		return command, true
+	}
+	owner, ok := owners[uid]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("runuser -u %s -- %s", shellQuote(owner), command), true
+}
+
+func loadVCSRepo(root, kind string, owners map[int]string) *vcsRepo {
+	repo := &vcsRepo{root: root, kind: kind}
+	uid := -1
+	if info, err := os.Lstat(filepath.Join(root, "."+kind)); err == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid = int(stat.Uid)
+		}
+	}
+	sets := []*map[string]bool{&repo.tracked, &repo.untracked, &repo.ignored}
+	for i, command := range vcsCommands[kind] {
+		command, ok := runAsOwner(uid, owners, fmt.Sprintf(command, shellQuote(root)))
+		if !ok {
+			fmt.Printf("Warning: Not querying %s repository %s: owned by unknown uid %d\n", kind, root, uid)
+			repo.failed = true
+			return repo
+		}
+		output, err := utils.Runner.RunWithOutput(command)
+		if err != nil {
+			fmt.Printf("Warning: Failed to query %s repository %s: %v\n", kind, root, err)
+			repo.failed = true
+			return repo
+		}
+		*sets[i] = vcsPathSet(output)
+	}
+	return repo
+}
+
+func vcsPathSet(output string) map[string]bool {
+	set := make(map[string]bool)
+	for _, path := range strings.Split(output, "\x00") {
+		path = strings.TrimSuffix(strings.TrimSpace(path), "/")
+		for ; path != "" && path != "." && !set[path]; path = filepath.Dir(path) {
+			set[path] = true
+		}
+	}
+	return set
+}
+
+func (x *vcsIndex) status(path string) vcsStatus {
+	root, kind := findVCSRoot(path)
+	if root == "" {
+		return vcsNone
+	}
+	repo, ok := x.repos[root]
+	if !ok {
+		repo = loadVCSRepo(root, kind, x.owners)
+		x.repos[root] = repo
+	}
+	if repo.failed {
+		return vcsUnknown
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return vcsUnknown
+	}
+	switch {
+	case repo.tracked[rel]:
+		return vcsTracked
+	case repo.untracked[rel]:
+		return vcsUntracked
+	}
+	for p := rel; p != "." && p != "/"; p = filepath.Dir(p) {
+		if repo.ignored[p] {
+			return vcsIgnored
+		}
+	}
+	return vcsUntracked
+}
+
+// artifactRemover only removes ignored artifacts inside a working tree: untracked ones may be work in progress.
+type artifactRemover struct {
+	vcs  *vcsIndex
+	d    *deleter
+	kept []string
+}
+
+func newArtifactRemover(d *deleter, owners map[int]string) *artifactRemover {
+	return &artifactRemover{vcs: newVCSIndex(owners), d: d}
+}
+
+func (r *artifactRemover) remove(path string) bool {
+	switch r.vcs.status(path) {
+	case vcsTracked:
+		r.kept = append(r.kept, path+" (tracked)")
+		return false
+	case vcsUntracked:
+		r.kept = append(r.kept, path+" (untracked, not ignored)")
+		return false
+	case vcsUnknown:
+		r.kept = append(r.kept, path+" (repository could not be queried)")
+		return false
+	}
+	return r.d.removeTree(path)
+}
+
+func (r *artifactRemover) removeIgnored(path string) bool {
+	if r.vcs.status(path) == vcsNone {
+		r.kept = append(r.kept, path+" (not in a version control working tree)")
+		return false
+	}
+	return r.remove(path)
+}
+
+func (r *artifactRemover) report(what string) {
+	r.d.report(what)
+	if len(r.kept) == 0 {
+		return
+	}
+	fmt.Printf("Kept %d artifact(s):\n", len(r.kept))
+	for _, path := range r.kept {
+		fmt.Printf("  %s\n", path)
+	}
+}
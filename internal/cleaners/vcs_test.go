@@ -0,0 +1,128 @@
+package cleaners
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArtifactRemoverGit(t *testing.T) {
+	mock := setupTest()
+	home := t.TempDir()
+	writeTestFiles(t, home,
+		"repo/.git/HEAD",
+		"repo/configure.ac",
+		"repo/Makefile.in",
+		"repo/aclocal.m4",
+		"repo/config.log",
+		"repo/build/CMakeCache.txt",
+		"repo/vendor/lib/Makefile.in",
+		"plain/Makefile.in",
+	)
+	mock.RunWithOutputFunc = func(command string) (string, error) {
+		switch {
+		case strings.HasSuffix(command, "--directory"):
+			return "build/\x00config.log\x00", nil
+		case strings.HasSuffix(command, "--exclude-standard"):
+			return "aclocal.m4\x00", nil
+		default:
+			return "Makefile.in\x00configure.ac\x00vendor/lib/Makefile.in\x00", nil
+		}
+	}
+
+	d := &deleter{}
+	r := newArtifactRemover(d, testOwners())
+	cleanAutotoolsFilesIn(home, r)
+	cleanCMakeBuildDirsIn(home, r)
+
+	assertFilesRemoved(t, home, "repo/config.log", "repo/build", "plain/Makefile.in")
+	assertFilesExist(t, home, "repo/Makefile.in", "repo/vendor/lib/Makefile.in", "repo/aclocal.m4")
+	if len(r.kept) != 3 {
+		t.Errorf("Expected 3 kept artifacts, got %v", r.kept)
+	}
+	// The repository is queried once, as its owner.
+	if len(mock.Commands) != 3 {
+		t.Fatalf("Expected 3 commands, got %v", mock.Commands)
+	}
+	if !strings.HasSuffix(mock.Commands[0], "git -C '"+home+"/repo' ls-files -z") || strings.Contains(mock.Commands[0], "safe.directory") {
+		t.Errorf("Unexpected command: %s", mock.Commands[0])
+	}
+}
+
+func TestArtifactRemoverMercurial(t *testing.T) {
+	mock := setupTest()
+	home := t.TempDir()
+	writeTestFiles(t, home, "repo/.hg/requires", "repo/autom4te.cache/output.0", "repo/config.status")
+	mock.RunWithOutputFunc = func(command string) (string, error) {
+		if strings.Contains(command, "status -i") {
+			return "autom4te.cache/output.0\x00", nil
+		}
+		return "", nil
+	}
+
+	r := newArtifactRemover(&deleter{}, testOwners())
+	cleanAutotoolsFilesIn(home, r)
+
+	assertFilesRemoved(t, home, "repo/autom4te.cache")
+	assertFilesExist(t, home, "repo/config.status")
+	if !strings.Contains(mock.Commands[0], "hg --cwd '"+home+"/repo' status") {
+		t.Errorf("Unexpected command: %s", mock.Commands[0])
+	}
+}
+
+func TestArtifactRemoverQueryFailure(t *testing.T) {
+	mock := setupTest()
+	home := t.TempDir()
+	writeTestFiles(t, home, "repo/.git/HEAD", "repo/config.log")
+	mock.RunWithOutputFunc = func(string) (string, error) { return "", errors.New("git not found") }
+
+	r := newArtifactRemover(&deleter{}, testOwners())
+	cleanAutotoolsFilesIn(home, r)
+
+	assertFilesExist(t, home, "repo/config.log")
+}
+
+func TestArtifactRemoverUnknownOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("Skipping test that changes file ownership when not running as root")
+	}
+	mock := setupTest()
+	home := t.TempDir()
+	writeTestFiles(t, home, "repo/.git/HEAD", "repo/config.log")
+	if err := os.Chown(filepath.Join(home, "repo/.git"), 4321, 4321); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newArtifactRemover(&deleter{}, testOwners())
+	cleanAutotoolsFilesIn(home, r)
+
+	assertFilesExist(t, home, "repo/config.log")
+	if len(mock.Commands) != 0 {
+		t.Errorf("Expected no commands for a repository of an unknown owner, got %v", mock.Commands)
+	}
+}
+
+func TestRunAsOwner(t *testing.T) {
+	owners := map[int]string{1000: "alice"}
+	tests := []struct {
+		uid      int
+		expected string
+		ok       bool
+	}{
+		{0, "git status", true},
+		{1000, "runuser -u 'alice' -- git status", true},
+		{1234, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := runAsOwner(tt.uid, owners, "git status"); got != tt.expected || ok != tt.ok {
+			t.Errorf("runAsOwner(%d) = %q, %v; want %q, %v", tt.uid, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+// testOwners maps the uid running the tests to a user name.
+func testOwners() map[int]string {
+	return map[int]string{os.Getuid(): "tester"}
+}
@@ -0,0 +1,98 @@
+package cleaners
+
+import (
+	"strings"
+)
+
+// compareVersions follows the Debian ordering rules.
+func compareVersions(a, b string) int {
+	epochA, restA := splitEpoch(a)
+	epochB, restB := splitEpoch(b)
+	if c := compareFragment(epochA, epochB); c != 0 {
+		return c
+	}
+
+	upA, revA := splitRevision(restA)
+	upB, revB := splitRevision(restB)
+	if c := compareFragment(upA, upB); c != 0 {
+		return c
+	}
+	return compareFragment(revA, revB)
+}
+
+func splitEpoch(v string) (string, string) {
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		return v[:i], v[i+1:]
+	}
+	return "0", v
+}
+
+func splitRevision(v string) (string, string) {
+	if i := strings.LastIndexByte(v, '-'); i >= 0 {
+		return v[:i], v[i+1:]
+	}
+	return v, ""
+}
+
+func compareFragment(a, b string) int {
+	for a != "" || b != "" {
+		// Compare the non-digit prefixes character by character.
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			ca, cb := versionOrder(a), versionOrder(b)
+			if ca != cb {
+				if ca < cb {
+					return -1
+				}
+				return 1
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		// Then the numeric runs, ignoring leading zeros.
+		var numA, numB string
+		numA, a = takeDigits(a)
+		numB, b = takeDigits(b)
+		numA = strings.TrimLeft(numA, "0")
+		numB = strings.TrimLeft(numB, "0")
+		if len(numA) != len(numB) {
+			if len(numA) < len(numB) {
+				return -1
+			}
+			return 1
+		}
+		if numA != numB {
+			if numA < numB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionOrder(s string) int {
+	if s == "" || isDigit(s[0]) {
+		return 0
+	}
+	c := s[0]
+	switch {
+	case c == '~':
+		return -1
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func takeDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,27 @@
+package cleaners
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0", "1.0a", -1},
+		{"1:1.0", "2.0", 1},
+		{"2.0-1", "2.0-2", -1},
+		{"5.15.0-91-generic", "5.15.0-100-generic", -1},
+		{"6.1.0-13-amd64", "6.1.0-13-amd64", 0},
+		{"6.5.0-14-generic", "6.2.0-39-generic", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersions(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
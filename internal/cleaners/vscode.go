@@ -0,0 +1,191 @@
+package cleaners
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cosmix/broom/internal/utils"
+)
+
+type vscodeInstall struct {
+	name       string
+	extensions string
+	userData   string
+}
+
+var vscodeInstalls = []vscodeInstall{
+	{"VS Code", ".vscode/extensions", ".config/Code"},
+	{"VS Code Insiders", ".vscode-insiders/extensions", ".config/Code - Insiders"},
+	{"VSCodium", ".vscode-oss/extensions", ".config/VSCodium"},
+	{"Code - OSS", ".vscode-oss/extensions", ".config/Code - OSS"},
+	{"Cursor", ".cursor/extensions", ".config/Cursor"},
+	{"VS Code (flatpak)", ".var/app/com.visualstudio.code/data/vscode/extensions", ".var/app/com.visualstudio.code/config/Code"},
+}
+
+type vscodeExtension struct {
+	dir     string
+	id      string
+	version string
+}
+
+func readVSCodeExtension(dir string) (vscodeExtension, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return vscodeExtension{}, false
+	}
+	var manifest struct {
+		Publisher string `json:"publisher"`
+		Name      string `json:"name"`
+		Version   string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Publisher == "" || manifest.Name == "" {
+		return vscodeExtension{}, false
+	}
+	id := strings.ToLower(manifest.Publisher + "." + manifest.Name)
+	return vscodeExtension{dir: dir, id: id, version: manifest.Version}, true
+}
+
+func readVSCodeExtensionsManifest(extensionsDir string) map[string]bool {
+	data, err := os.ReadFile(filepath.Join(extensionsDir, "extensions.json"))
+	if err != nil {
+		return nil
+	}
+	var entries []struct {
+		RelativeLocation string `json:"relativeLocation"`
+		Location         struct {
+			Path string `json:"path"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	listed := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.RelativeLocation != "" {
+			listed[filepath.Join(extensionsDir, entry.RelativeLocation)] = true
+		} else if entry.Location.Path != "" {
+			listed[filepath.Clean(entry.Location.Path)] = true
+		}
+	}
+	return listed
+}
+
+// selectOldExtensions treats extensions.json as authoritative when present; otherwise superseded versions go.
+func selectOldExtensions(extensions []vscodeExtension, listed map[string]bool) []string {
+	var old []string
+	if listed != nil {
+		for _, ext := range extensions {
+			if !listed[ext.dir] {
+				old = append(old, ext.dir)
+			}
+		}
+		return old
+	}
+
+	newest := make(map[string]vscodeExtension)
+	for _, ext := range extensions {
+		if current, ok := newest[ext.id]; !ok || compareVersions(ext.version, current.version) > 0 {
+			newest[ext.id] = ext
+		}
+	}
+	for _, ext := range extensions {
+		if newest[ext.id].dir != ext.dir {
+			old = append(old, ext.dir)
+		}
+	}
+	return old
+}
+
+func staleWorkspaceStorage(storageDir string) []string {
+	entries, err := os.ReadDir(storageDir)
+	if err != nil {
+		return nil
+	}
+	var stale []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(storageDir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "workspace.json"))
+		if err != nil {
+			continue
+		}
+		var workspace struct {
+			Folder    string `json:"folder"`
+			Workspace string `json:"workspace"`
+		}
+		if err := json.Unmarshal(data, &workspace); err != nil {
+			continue
+		}
+		target := workspace.Folder
+		if target == "" {
+			target = workspace.Workspace
+		}
+		if target != "" && localFileGone(target) {
+			stale = append(stale, dir)
+		}
+	}
+	return stale
+}
+
+func cleanVSCodeIn(home, proc string, d *deleter) {
+	running := make(map[string]bool)
+	inUse := make(map[string]bool)
+	for _, install := range vscodeInstalls {
+		if pid, ok := chromiumRunningPid(filepath.Join(home, install.userData), proc); ok {
+			fmt.Printf("Skipping %s in %s: editor is running (pid %d)\n", install.name, home, pid)
+			running[install.name] = true
+			inUse[filepath.Join(home, install.extensions)] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, install := range vscodeInstalls {
+		if running[install.name] {
+			continue
+		}
+		extensionsDir := filepath.Join(home, install.extensions)
+		userData := filepath.Join(home, install.userData)
+
+		before := d.freed
+		// VSCodium and Code - OSS share their extensions directory, which is
+		// only looked at for the first of them.
+		if !seen[extensionsDir] && !inUse[extensionsDir] {
+			var extensions []vscodeExtension
+			entries, _ := os.ReadDir(extensionsDir)
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					continue
+				}
+				if ext, ok := readVSCodeExtension(filepath.Join(extensionsDir, entry.Name())); ok {
+					extensions = append(extensions, ext)
+				}
+			}
+			for _, dir := range selectOldExtensions(extensions, readVSCodeExtensionsManifest(extensionsDir)) {
+				d.removeTree(dir)
+			}
+		}
+		seen[extensionsDir] = true
+		for _, dir := range staleWorkspaceStorage(filepath.Join(userData, "User/workspaceStorage")) {
+			d.removeTree(dir)
+		}
+		d.removeContents(filepath.Join(userData, "CachedExtensionVSIXs"))
+
+		if freed := d.freed - before; freed > 0 {
+			fmt.Printf("%s in %s: %s freed\n", install.name, home, utils.FormatBytes(uint64(freed)))
+		}
+	}
+}
+
+func cleanVSCode() error {
+	d := newDeleter()
+	for _, home := range userHomes(passwdFile) {
+		cleanVSCodeIn(home.dir, procDir, d)
+	}
+	d.report("VS Code file(s)")
+	return nil
+}
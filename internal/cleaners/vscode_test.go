@@ -0,0 +1,108 @@
+package cleaners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestExtension(t *testing.T, extensionsDir, dir, publisher, name, version string) {
+	t.Helper()
+	manifest := `{"publisher": "` + publisher + `", "name": "` + name + `", "version": "` + version + `"}`
+	path := filepath.Join(extensionsDir, dir, "package.json")
+	writeTestFile(t, path, manifest)
+}
+
+func TestCleanVSCode(t *testing.T) {
+	home := t.TempDir()
+	project := t.TempDir()
+	extensions := filepath.Join(home, ".vscode/extensions")
+	writeTestExtension(t, extensions, "ms-python.python-2024.2.1", "ms-python", "python", "2024.2.1")
+	writeTestExtension(t, extensions, "ms-python.python-2024.10.0", "ms-python", "python", "2024.10.0")
+	writeTestExtension(t, extensions, "golang.go-0.40.0", "golang", "Go", "0.40.0")
+	writeTestExtension(t, extensions, "golang.go-0.41.0", "golang", "Go", "0.41.0")
+	writeTestExtension(t, extensions, "golang.go-0.39.0", "golang", "Go", "0.39.0")
+	manifest := `[{"identifier": {"id": "ms-python.python"}, "version": "2024.10.0", "relativeLocation": "ms-python.python-2024.10.0"},
+		{"identifier": {"id": "golang.go"}, "version": "0.40.0", "relativeLocation": "golang.go-0.40.0"}]`
+	writeTestFile(t, filepath.Join(extensions, "extensions.json"), manifest)
+
+	storage := filepath.Join(home, ".config/Code/User/workspaceStorage")
+	workspaces := map[string]string{
+		"aaa": `{"folder": "file://` + project + `"}`,
+		"bbb": `{"folder": "file://` + filepath.Join(project, "deleted") + `"}`,
+		"ccc": `{"workspace": "file://` + filepath.Join(project, "gone.code-workspace") + `"}`,
+		"ddd": `{"folder": "vscode-remote://ssh-remote+host/srv/app"}`,
+	}
+	for dir, content := range workspaces {
+		writeTestFiles(t, storage, filepath.Join(dir, "state.vscdb"))
+		writeTestFile(t, filepath.Join(storage, dir, "workspace.json"), content)
+	}
+	writeTestFiles(t, home, ".config/Code/CachedExtensionVSIXs/golang.go-0.41.0")
+
+	// Without extensions.json, only superseded versions go.
+	cursorExtensions := filepath.Join(home, ".cursor/extensions")
+	writeTestExtension(t, cursorExtensions, "golang.go-0.40.0", "golang", "Go", "0.40.0")
+	writeTestExtension(t, cursorExtensions, "golang.go-0.41.0", "golang", "Go", "0.41.0")
+
+	d := &deleter{}
+	cleanVSCodeIn(home, t.TempDir(), d)
+
+	// extensions.json lists what is installed, even over a newer version.
+	assertFilesRemoved(t, extensions, "ms-python.python-2024.2.1", "golang.go-0.39.0", "golang.go-0.41.0")
+	assertFilesExist(t, extensions, "ms-python.python-2024.10.0", "golang.go-0.40.0")
+	assertFilesRemoved(t, cursorExtensions, "golang.go-0.40.0")
+	assertFilesExist(t, cursorExtensions, "golang.go-0.41.0")
+	assertFilesRemoved(t, storage, "bbb", "ccc")
+	assertFilesExist(t, storage, "aaa", "ddd")
+	assertFilesRemoved(t, home, ".config/Code/CachedExtensionVSIXs/golang.go-0.41.0")
+	assertFilesExist(t, home, ".config/Code/CachedExtensionVSIXs")
+}
+
+func TestCleanVSCodeRunning(t *testing.T) {
+	home := t.TempDir()
+	proc := t.TempDir()
+	extensions := filepath.Join(home, ".vscode/extensions")
+	writeTestExtension(t, extensions, "golang.go-0.40.0", "golang", "Go", "0.40.0")
+	writeTestExtension(t, extensions, "golang.go-0.41.0", "golang", "Go", "0.41.0")
+	writeTestFiles(t, home, ".config/Code/CachedExtensionVSIXs/golang.go-0.41.0")
+	if err := os.MkdirAll(filepath.Join(proc, "4321"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("my-host-4321", filepath.Join(home, ".config/Code/SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &deleter{}
+	cleanVSCodeIn(home, proc, d)
+
+	assertFilesExist(t, extensions, "golang.go-0.40.0")
+	assertFilesExist(t, home, ".config/Code/CachedExtensionVSIXs/golang.go-0.41.0")
+	if d.removed != 0 {
+		t.Errorf("Expected nothing removed, got %d", d.removed)
+	}
+}
+
+func TestCleanVSCodeSharedExtensions(t *testing.T) {
+	home := t.TempDir()
+	proc := t.TempDir()
+	extensions := filepath.Join(home, ".vscode-oss/extensions")
+	writeTestExtension(t, extensions, "golang.go-0.40.0", "golang", "Go", "0.40.0")
+	writeTestExtension(t, extensions, "golang.go-0.41.0", "golang", "Go", "0.41.0")
+	writeTestFiles(t, home, ".config/Code - OSS/CachedExtensionVSIXs/golang.go-0.41.0")
+	if err := os.MkdirAll(filepath.Join(proc, "4321"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".config/VSCodium"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("my-host-4321", filepath.Join(home, ".config/VSCodium/SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+
+	// VSCodium is running, so the extensions it shares with Code - OSS stay.
+	d := &deleter{}
+	cleanVSCodeIn(home, proc, d)
+
+	assertFilesExist(t, extensions, "golang.go-0.40.0", "golang.go-0.41.0")
+	assertFilesRemoved(t, home, ".config/Code - OSS/CachedExtensionVSIXs/golang.go-0.41.0")
+}
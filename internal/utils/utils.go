@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"os/exec"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
 )
 
 // UtilsRunner interface for mocking utils functions
@@ -18,6 +20,7 @@ type UtilsRunner interface {
 	RunWithIndicator(command, message string) error
 	RunFdOrFind(path, args, message string, sudo bool) error
 	RunWithOutput(command string) (string, error)
+	RunWithIO(command string, stdin io.Reader, stdout io.Writer) error
 }
 
 // DefaultUtilsRunner implements UtilsRunner with actual utils functions
@@ -35,6 +38,10 @@ func (r DefaultUtilsRunner) RunWithOutput(command string) (string, error) {
 	return RunWithOutput(command)
 }
 
+func (r DefaultUtilsRunner) RunWithIO(command string, stdin io.Reader, stdout io.Writer) error {
+	return RunWithIO(command, stdin, stdout)
+}
+
 var Runner UtilsRunner = DefaultUtilsRunner{}
 
 // SetUtilsRunner allows injection of a custom UtilsRunner (useful for testing)
@@ -45,6 +52,9 @@ func SetUtilsRunner(r UtilsRunner) {
 // CommandExistsFunc is a function type for checking if a command exists
 type CommandExistsFunc func(string) bool
 
+// ConfirmFunc is a function type for asking the user a yes/no question
+type ConfirmFunc func(string) bool
+
 // GetFreeDiskSpace returns the amount of free disk space in bytes
 func GetFreeDiskSpace() uint64 {
 	var stat syscall.Statfs_t
@@ -118,12 +128,33 @@ func RunWithOutput(command string) (string, error) {
 	return string(output), nil
 }
 
+// RunWithIO executes a command reading from stdin and writing its output to stdout
+func RunWithIO(command string, stdin io.Reader, stdout io.Writer) error {
+	cmd := exec.Command("bash", "-c", command)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error executing command: %v", err)
+	}
+	return nil
+}
+
 // CommandExists checks if a command exists in the PATH
 func CommandExists(cmd string) bool {
 	_, err := exec.LookPath(cmd)
 	return err == nil
 }
 
+// Confirm asks the user a yes/no question and reports whether they agreed
+func Confirm(label string) bool {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+	result, err := prompt.Run()
+	return err == nil && strings.ToLower(result) == "y"
+}
+
 // CheckRoot checks if the program is running as root
 func CheckRoot() {
 	if os.Geteuid() != 0 {
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"bytes"
 	"io"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -70,6 +72,20 @@ func TestRunFdOrFind(t *testing.T) {
 	}
 }
 
+func TestRunWithIO(t *testing.T) {
+	var out bytes.Buffer
+	if err := RunWithIO("tr a-z A-Z", strings.NewReader("hello\n"), &out); err != nil {
+		t.Fatalf("RunWithIO returned an error for valid command: %v", err)
+	}
+	if out.String() != "HELLO\n" {
+		t.Errorf("RunWithIO wrote %q; want %q", out.String(), "HELLO\n")
+	}
+
+	if err := RunWithIO("exit 3", nil, io.Discard); err == nil {
+		t.Error("RunWithIO should have returned an error for failing command")
+	}
+}
+
 func TestCommandExists(t *testing.T) {
 	if !CommandExists("ls") {
 		t.Error("CommandExists returned false for 'ls', expected true")